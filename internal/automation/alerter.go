@@ -0,0 +1,69 @@
+package automation
+
+import (
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+/*
+Posts operational problems to the admins' alerts channel.
+*/
+type Alerter struct {
+	session   *discordgo.Session
+	channelID string
+}
+
+/*
+Creates a new Alerter.
+It returns nil if the feature is not configured, alerts are then only logged.
+*/
+func NewAlerter(s *discordgo.Session, cfg *config.Config) *Alerter {
+	if cfg.AlertsChannelID == "" {
+		log.Println("[DISCORD] ALERTS_CHANNEL_ID not set, alerts will only be logged")
+		return nil
+	}
+	return &Alerter{
+		session:   s,
+		channelID: cfg.AlertsChannelID,
+	}
+}
+
+/*
+Logs the alert and posts it to the alerts channel.
+*/
+func (a *Alerter) Alert(title, description string) {
+	log.Printf("[ALERT] %s: %s", title, description)
+	a.send(title, description, 0xff0000)
+}
+
+/*
+Logs and posts that a previously alerted problem is solved.
+*/
+func (a *Alerter) Resolve(title, description string) {
+	log.Printf("[ALERT] %s: %s", title, description)
+	a.send(title, description, 0x00ff00)
+}
+
+func (a *Alerter) send(title, description string, color int) {
+	if a == nil {
+		return // Alerts are only logged
+	}
+
+	// Embed descriptions are limited to 4096 characters.
+	if len(description) > 4000 {
+		description = description[:4000] + "..."
+	}
+
+	_, err := a.session.ChannelMessageSendEmbed(a.channelID, &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to send alert %q: %v", title, err)
+	}
+}
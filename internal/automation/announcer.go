@@ -0,0 +1,116 @@
+package automation
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/schedule"
+)
+
+// Schedules have a one minute resolution, announcements are posted at most this late.
+const announceCheckInterval = 20 * time.Second
+
+/*
+Posts the announcements created with /zannounce when they are due and deletes their posts when their TTL ends.
+Announcements and pending deletions are stored in the database, so they survive restarts.
+Runs missed while the bot was offline are posted once when it is back.
+*/
+type Announcer struct {
+	session  *discordgo.Session
+	db       *sql.DB
+	location *time.Location
+	// Ready fires again after reconnects, a second loop would post every announcement twice.
+	startOnce sync.Once
+}
+
+/*
+Creates a new Announcer service.
+Schedules follow ANNOUNCE_TIMEZONE, the timezone of the host when it is not set.
+*/
+func NewAnnouncer(s *discordgo.Session, db *sql.DB, cfg *config.Config) *Announcer {
+	return &Announcer{
+		session:  s,
+		db:       db,
+		location: cfg.AnnounceLocation,
+	}
+}
+
+func (a *Announcer) Start() {
+	a.startOnce.Do(func() {
+		log.Println("[DISCORD] Starting 'Announcer'...")
+
+		ticker := time.NewTicker(announceCheckInterval)
+		go func() {
+			a.check(time.Now())
+			for now := range ticker.C {
+				a.check(now)
+			}
+		}()
+	})
+}
+
+func (a *Announcer) check(now time.Time) {
+	a.deleteExpiredPosts(now)
+
+	announcements, err := helpers.DueAnnouncementsReader(a.db, now)
+	if err != nil {
+		return
+	}
+	for _, announcement := range announcements {
+		a.post(announcement, now)
+	}
+}
+
+/*
+Posts a due announcement, then schedules its next run or removes it when it was a one-off.
+*/
+func (a *Announcer) post(announcement helpers.Announcement, now time.Time) {
+	message, err := a.session.ChannelMessageSend(announcement.ChannelID, announcement.Content)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to post announcement %d in channel %s: %v", announcement.ID, announcement.ChannelID, err)
+		// Network errors are retried on the next check, Discord refusing the message isn't.
+		var restErr *discordgo.RESTError
+		if !errors.As(err, &restErr) || restErr.Message == nil {
+			return
+		}
+	} else if announcement.TTL > 0 {
+		helpers.AddAnnouncementPost(a.db, message.ChannelID, message.ID, now.Add(announcement.TTL))
+	}
+
+	if announcement.Schedule == "" {
+		helpers.DeleteAnnouncement(a.db, announcement.ID)
+		return
+	}
+	cron, err := schedule.Parse(announcement.Schedule)
+	if err != nil {
+		log.Printf("[DISCORD] Removing announcement %d with an invalid schedule: %v", announcement.ID, err)
+		helpers.DeleteAnnouncement(a.db, announcement.ID)
+		return
+	}
+	helpers.SetAnnouncementNextRun(a.db, announcement.ID, cron.Next(now.In(a.location)))
+}
+
+func (a *Announcer) deleteExpiredPosts(now time.Time) {
+	posts, err := helpers.ExpiredAnnouncementPostsReader(a.db, now)
+	if err != nil {
+		return
+	}
+	for _, post := range posts {
+		err := a.session.ChannelMessageDelete(post.ChannelID, post.MessageID)
+		var restErr *discordgo.RESTError
+		if err != nil && !(errors.As(err, &restErr) && restErr.Message != nil) {
+			log.Printf("[DISCORD] Failed to delete announcement post %s, retrying: %v", post.MessageID, err)
+			continue
+		}
+		if err != nil {
+			log.Printf("[DISCORD] Failed to delete announcement post %s: %v", post.MessageID, err)
+		}
+		helpers.RemoveAnnouncementPost(a.db, post.MessageID)
+	}
+}
@@ -0,0 +1,314 @@
+package automation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/commands"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+// Custom ID prefixes of the appeal buttons and form, followed by ":" and the case number.
+const (
+	AppealButtonID = "appeal"
+	AppealFormID   = "appeal_form"
+	AppealUnbanID  = "appeal_unban"
+	AppealKeepID   = "appeal_keep"
+)
+
+/*
+Lets users banned by the bot appeal from their DMs and moderators review the appeals.
+Every step is recorded in the case history.
+*/
+type Appeals struct {
+	session         *discordgo.Session
+	db              *sql.DB
+	reviewChannelID string
+}
+
+/*
+Creates a new Appeals service.
+Appeals are reviewed in APPEAL_CHANNEL_ID, or in the mod log channel when it is not set.
+Without either, banned users are still told about their case but can't appeal.
+*/
+func NewAppeals(s *discordgo.Session, db *sql.DB, cfg *config.Config) *Appeals {
+	reviewChannelID := cfg.AppealChannelID
+	if reviewChannelID == "" {
+		reviewChannelID = cfg.ModLogChannelID
+	}
+	if reviewChannelID == "" {
+		log.Println("[DISCORD] APPEAL_CHANNEL_ID and MOD_LOG_CHANNEL_ID not set, banned users won't be able to appeal")
+	}
+	return &Appeals{
+		session:         s,
+		db:              db,
+		reviewChannelID: reviewChannelID,
+	}
+}
+
+/*
+Tells the user about their ban case by DM, with an appeal button.
+It has to run before the ban, Discord doesn't deliver DMs from bots without a shared server.
+*/
+func (a *Appeals) NotifyBan(guildID string, modCase *helpers.ModCase) {
+	canAppeal := a.reviewChannelID != ""
+	message := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{commands.BanNotice(a.guildName(guildID), modCase, canAppeal)},
+	}
+	if canAppeal {
+		message.Components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Appeal",
+					Style:    discordgo.PrimaryButton,
+					CustomID: fmt.Sprintf("%s:%d", AppealButtonID, modCase.ID),
+				},
+			}},
+		}
+	}
+
+	if a.sendDM(modCase, message) {
+		helpers.AddCaseEvent(a.db, modCase.ID, helpers.CaseEventNotified, "", "")
+	}
+}
+
+/*
+Tells a user who got a ban notice that the ban failed, so they don't believe they are banned.
+Users who never got the notice are left alone.
+*/
+func (a *Appeals) RetractBan(guildID string, modCase *helpers.ModCase) {
+	notified := false
+	for _, event := range helpers.CaseEventsReader(a.db, modCase.ID) {
+		notified = notified || event.Event == helpers.CaseEventNotified
+	}
+	if !notified {
+		return
+	}
+
+	message := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{commands.BanRetracted(a.guildName(guildID), modCase)},
+	}
+	if a.sendDM(modCase, message) {
+		helpers.AddCaseEvent(a.db, modCase.ID, helpers.CaseEventRetracted, "", modCase.Error)
+	}
+}
+
+/*
+DMs the user of the case, recording a notify failed event when the DM can't be delivered.
+*/
+func (a *Appeals) sendDM(modCase *helpers.ModCase, message *discordgo.MessageSend) bool {
+	channel, err := a.session.UserChannelCreate(modCase.UserID)
+	if err == nil {
+		_, err = a.session.ChannelMessageSendComplex(channel.ID, message)
+	}
+	if err != nil {
+		reason := err.Error()
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser {
+			reason = "The user doesn't accept DMs"
+		}
+		log.Printf("[DISCORD] Failed to DM %s (%s) about case %d: %v", modCase.Username, modCase.UserID, modCase.ID, err)
+		helpers.AddCaseEvent(a.db, modCase.ID, helpers.CaseEventNotifyFailed, "", reason)
+		return false
+	}
+	return true
+}
+
+func (a *Appeals) guildName(guildID string) string {
+	if guild, err := a.session.State.Guild(guildID); err == nil {
+		return guild.Name
+	}
+	return "the server"
+}
+
+/*
+Opens the appeal form when the user presses the appeal button of their DM.
+*/
+func (a *Appeals) HandleAppealButton(s *discordgo.Session, i *discordgo.InteractionCreate, caseID int64) {
+	modCase, problem := a.appealableCase(i, caseID)
+	if problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("%s:%d", AppealFormID, modCase.ID),
+			Title:    fmt.Sprintf("Appeal of case #%d", modCase.ID),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "appeal",
+						Label:       "Why should you be unbanned?",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "Explain what happened, e.g. your account was compromised",
+						Required:    true,
+						MinLength:   10,
+						MaxLength:   1500,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to open appeal form of case %d: %v", caseID, err)
+	}
+}
+
+/*
+Posts a submitted appeal for review, then records it and removes the appeal button from the DM.
+The user is asked to try again when the appeal can't be posted, the button stays so they can.
+*/
+func (a *Appeals) HandleAppealForm(s *discordgo.Session, i *discordgo.InteractionCreate, caseID int64) {
+	modCase, problem := a.appealableCase(i, caseID)
+	if problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
+
+	appeal := modalValue(i.ModalSubmitData().Components, "appeal")
+	_, err := s.ChannelMessageSendComplex(a.reviewChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{commands.AppealReview(modCase, appeal, "")},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Unban", Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("%s:%d", AppealUnbanID, modCase.ID)},
+				discordgo.Button{Label: "Keep", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("%s:%d", AppealKeepID, modCase.ID)},
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to post appeal of case %d for review: %v", modCase.ID, err)
+		respondEphemeral(s, i, "Your appeal couldn't be sent to the moderators, please try again later.")
+		return
+	}
+
+	// The moderators already have the appeal, a failure here only loses it from the case history.
+	helpers.AddCaseEvent(a.db, modCase.ID, helpers.CaseEventAppealed, modCase.UserID, appeal)
+	log.Printf("[DISCORD] User %s (%s) appealed case %d", modCase.Username, modCase.UserID, modCase.ID)
+
+	var embeds []*discordgo.MessageEmbed
+	if i.Message != nil {
+		embeds = i.Message.Embeds
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "Your appeal was sent to the moderators.",
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to confirm appeal of case %d: %v", modCase.ID, err)
+	}
+}
+
+/*
+Applies a moderator's decision on an appeal and updates the review message.
+Callers check that the moderator is an admin.
+*/
+func (a *Appeals) HandleReview(s *discordgo.Session, i *discordgo.InteractionCreate, caseID int64, unban bool) {
+	modCase := helpers.ModCaseReader(a.db, caseID)
+	if modCase == nil {
+		respondEphemeral(s, i, fmt.Sprintf("Case #%d not found.", caseID))
+		return
+	}
+
+	var appeal string
+	for _, event := range helpers.CaseEventsReader(a.db, caseID) {
+		switch event.Event {
+		case helpers.CaseEventAppealed:
+			appeal = event.Note
+		case helpers.CaseEventUnbanned, helpers.CaseEventKept:
+			respondEphemeral(s, i, fmt.Sprintf("This appeal was already reviewed by <@%s>.", event.ActorID))
+			return
+		}
+	}
+
+	moderatorID := i.Member.User.ID
+	event, outcome := helpers.CaseEventKept, fmt.Sprintf("Ban kept by <@%s>", moderatorID)
+	if unban {
+		err := s.GuildBanDelete(i.GuildID, modCase.UserID)
+		var restErr *discordgo.RESTError
+		if err != nil && !(errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownBan) {
+			log.Printf("[DISCORD] Failed to unban %s (%s) for case %d: %v", modCase.Username, modCase.UserID, caseID, err)
+			respondEphemeral(s, i, fmt.Sprintf("Failed to unban the user: %v", err))
+			return
+		}
+		event, outcome = helpers.CaseEventUnbanned, fmt.Sprintf("Unbanned by <@%s>", moderatorID)
+	}
+	helpers.AddCaseEvent(a.db, caseID, event, moderatorID, "")
+	log.Printf("[DISCORD] Appeal of case %d reviewed by %s: %s", caseID, i.Member.User.Username, event)
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{commands.AppealReview(modCase, appeal, outcome)},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to update appeal review of case %d: %v", caseID, err)
+	}
+}
+
+/*
+Returns the case the interaction user wants to appeal, or why they can't.
+*/
+func (a *Appeals) appealableCase(i *discordgo.InteractionCreate, caseID int64) (*helpers.ModCase, string) {
+	if a.reviewChannelID == "" {
+		return nil, "Appeals are closed, contact a moderator with your case number."
+	}
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	modCase := helpers.ModCaseReader(a.db, caseID)
+	if modCase == nil || user == nil || modCase.UserID != user.ID || modCase.Action != "ban" {
+		return nil, "This case can't be appealed."
+	}
+	if modCase.Error != "" {
+		return nil, "This ban wasn't applied, there is nothing to appeal."
+	}
+	for _, event := range helpers.CaseEventsReader(a.db, caseID) {
+		if event.Event == helpers.CaseEventAppealed {
+			return nil, "You already appealed this case, the moderators will review it."
+		}
+	}
+	return modCase, ""
+}
+
+func modalValue(components []discordgo.MessageComponent, customID string) string {
+	for _, component := range components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, inner := range row.Components {
+			if input, ok := inner.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return strings.TrimSpace(input.Value)
+			}
+		}
+	}
+	return ""
+}
+
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to send ephemeral message: %v", err)
+	}
+}
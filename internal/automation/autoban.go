@@ -1,57 +1,218 @@
 package automation
 
 import (
+	"database/sql"
+	"fmt"
 	"log"
-	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/moderation"
 )
 
+// Discord refuses timeouts longer than 28 days.
+const maxTimeout = 28 * 24 * time.Hour
+
 type AutoBan struct {
-	bannedWords []string
+	db       *sql.DB
+	engine   *moderation.Engine
+	dryRun   bool
+	modLog   *ModLog
+	appeals  *Appeals
+	adminIDs []string
 }
 
 /*
 NewAutoBan creates and initializes a new AutoBan service.
-It processes the banned words from the configuration once at startup.
+The rules live in the database, the legacy banned words and the rules file only fill it the first time.
 */
-func NewAutoBan(cfg *config.Config) *AutoBan {
-	bannedWordsStr := cfg.BannedWords
-	words := strings.Split(bannedWordsStr, ",")
-	processedWords := make([]string, 0, len(words))
-
-	for _, word := range words {
-		trimmedWord := strings.TrimSpace(word)
-		if trimmedWord != "" {
-			processedWords = append(processedWords, strings.ToLower(trimmedWord))
+func NewAutoBan(cfg *config.Config, db *sql.DB, modLog *ModLog, appeals *Appeals) (*AutoBan, error) {
+	rules := moderation.LegacyRules(cfg.BannedWords)
+	if cfg.ModRulesPath != "" {
+		fileRules, err := moderation.LoadRules(cfg.ModRulesPath)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+	for _, rule := range rules {
+		if err := moderation.ValidateRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid moderation rule: %w", err)
 		}
 	}
 
-	return &AutoBan{
-		bannedWords: processedWords,
+	seeded, err := helpers.SeedModRules(db, rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store moderation rules: %w", err)
+	}
+	engine, err := moderation.NewEngine(nil, cfg.ModEscalationWindow)
+	if err != nil {
+		return nil, err
+	}
+	ab := &AutoBan{
+		db:       db,
+		engine:   engine,
+		dryRun:   cfg.ModDryRun,
+		modLog:   modLog,
+		appeals:  appeals,
+		adminIDs: cfg.AdminIDs,
+	}
+	if err := ab.ReloadRules(); err != nil {
+		return nil, err
+	}
+	if seeded {
+		log.Printf("[DISCORD] Stored %d moderation rules from BANNED_WORDS and MOD_RULES_PATH, use /zfilter to change them", engine.RuleCount())
+	}
+
+	if cfg.ModDryRun {
+		log.Printf("[DISCORD] Moderation dry run enabled, %d rules will only be reported", engine.RuleCount())
+	}
+	return ab, nil
+}
+
+/*
+Reads the rules from the database again, messages are evaluated against the new rules right away.
+*/
+func (ab *AutoBan) ReloadRules() error {
+	stored, err := helpers.ModRulesReader(ab.db)
+	if err != nil {
+		return err
+	}
+	rules := make([]moderation.Rule, 0, len(stored))
+	for _, rule := range stored {
+		rules = append(rules, rule.Rule)
 	}
+	if err := ab.engine.SetRules(rules); err != nil {
+		return fmt.Errorf("invalid moderation rule: %w", err)
+	}
+	return nil
+}
+
+func (ab *AutoBan) RuleCount() int {
+	return ab.engine.RuleCount()
 }
 
 /*
-Checks if the message content contains any of the banned words and bans the author if it does.
+Returns every rule the message would break, without acting on it.
+*/
+func (ab *AutoBan) Test(message string) []moderation.Match {
+	return ab.engine.Test(message)
+}
+
+/*
+Checks the message against the moderation rules and applies the action of the rule it breaks.
+In dry run mode the action is only reported to the mod log channel.
 */
 func (ab *AutoBan) MessageCreateHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.Author.Bot {
+	if m.Author == nil || m.Author.Bot || m.GuildID == "" {
+		return
+	}
+	if helpers.IsAdmin(m.Author.ID, ab.adminIDs) {
+		return
+	}
+
+	verdict := ab.engine.Evaluate(m.Author.ID, m.Content, time.Now())
+	if verdict == nil {
+		return
+	}
+
+	if ab.dryRun {
+		log.Printf("[DISCORD] Dry run: would %s user %s (%s) for breaking rule %q", verdict.Action, m.Author.Username, m.Author.ID, verdict.Rule)
+		ab.reportDryRun(m, verdict)
 		return
 	}
 
-	messageContent := strings.ToLower(m.Content)
+	modCase := &helpers.ModCase{
+		UserID:    m.Author.ID,
+		Username:  m.Author.Username,
+		Action:    verdict.Action.String(),
+		Rule:      verdict.Rule,
+		ChannelID: m.ChannelID,
+		Content:   m.Content,
+	}
+	if verdict.Action == moderation.ActionTimeout {
+		modCase.Duration = min(verdict.Timeout, maxTimeout)
+	}
+	if verdict.Offenses > 1 {
+		modCase.Reason = fmt.Sprintf("Offense %d within the escalation window", verdict.Offenses)
+	}
+	for _, attachment := range m.Attachments {
+		modCase.Attachments = append(modCase.Attachments, attachment.URL)
+	}
+
+	// The case is opened first so a banned user can be told its number while they can still get DMs.
+	_, caseErr := ab.modLog.Open(modCase)
+	if caseErr == nil && verdict.Action == moderation.ActionBan {
+		ab.appeals.NotifyBan(m.GuildID, modCase)
+	}
+
+	err := ab.apply(s, m, verdict)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to %s user %s (%s) for breaking rule %q: %v", verdict.Action, m.Author.Username, m.Author.ID, verdict.Rule, err)
+		modCase.Error = err.Error()
+	} else {
+		log.Printf("[DISCORD] User %s (%s) got a %s for breaking rule %q.", m.Author.Username, m.Author.ID, verdict.Action, verdict.Rule)
+	}
+	if caseErr == nil {
+		// Publishing stores the error, the case can't be appealed from then on.
+		ab.modLog.Publish(modCase)
+		if modCase.Error != "" && verdict.Action == moderation.ActionBan {
+			ab.appeals.RetractBan(m.GuildID, modCase)
+		}
+	}
+}
+
+/*
+Deletes the message, then times out, kicks or bans its author.
+*/
+func (ab *AutoBan) apply(s *discordgo.Session, m *discordgo.MessageCreate, verdict *moderation.Verdict) error {
+	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+		log.Printf("[DISCORD] Failed to delete message %s breaking rule %q: %v", m.ID, verdict.Rule, err)
+	}
+
+	reason := fmt.Sprintf("Automatic moderation: %s", verdict.Rule)
+	switch verdict.Action {
+	case moderation.ActionTimeout:
+		until := time.Now().Add(min(verdict.Timeout, maxTimeout))
+		return s.GuildMemberTimeout(m.GuildID, m.Author.ID, &until)
+	case moderation.ActionKick:
+		return s.GuildMemberDeleteWithReason(m.GuildID, m.Author.ID, reason)
+	case moderation.ActionBan:
+		return s.GuildBanCreateWithReason(m.GuildID, m.Author.ID, reason, 0)
+	}
+	return nil
+}
+
+/*
+Reports what would have been done to the mod log channel, without opening a case.
+*/
+func (ab *AutoBan) reportDryRun(m *discordgo.MessageCreate, verdict *moderation.Verdict) {
+	action := verdict.Action.String()
+	if verdict.Action == moderation.ActionTimeout {
+		action = fmt.Sprintf("timeout (%s)", helpers.FormatDuration(min(verdict.Timeout, maxTimeout)))
+	}
 
-	for _, word := range ab.bannedWords {
-		if strings.Contains(messageContent, word) {
-			err := s.GuildBanCreateWithReason(m.GuildID, m.Author.ID, "Automatic ban: Use of forbidden language.", 0)
-			if err != nil {
-				log.Printf("[DISCORD] Failed to auto-ban user %s (%s): %v", m.Author.Username, m.Author.ID, err)
-			} else {
-				log.Printf("[DISCORD] User %s (%s) has been banned for using a forbidden word.", m.Author.Username, m.Author.ID)
-			}
-			return
+	content := m.Content
+	if len(content) > 990 {
+		cut := 990
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
 		}
+		content = content[:cut] + "..."
 	}
+	ab.modLog.Post(&discordgo.MessageEmbed{
+		Title: "Dry run: would " + action,
+		Color: 0xffa600,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "User", Value: fmt.Sprintf("<@%s> (%s)", m.Author.ID, m.Author.Username), Inline: true},
+			{Name: "Channel", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+			{Name: "Rule", Value: verdict.Rule, Inline: true},
+			{Name: "Offenses", Value: fmt.Sprint(verdict.Offenses), Inline: true},
+			{Name: "Message", Value: codeBlock(content, 1000)},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
 }
@@ -1,8 +1,15 @@
 package automation
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"fmt"
 	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -10,27 +17,57 @@ import (
 	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
 )
 
+// How long the reload hook may run before it gets killed.
+const reloadCommandTimeout = 30 * time.Second
+
 type FileUpdater struct {
 	updateInterval time.Duration
 	session        *discordgo.Session
 	folderPath     string
 	db             *sql.DB
 	allowedMaps    []config.MapInfo
+	template       *template.Template
+	reloadCommand  []string
+	alerter        *Alerter
+	lastHash       [sha256.Size]byte
+	failing        bool
 }
 
-func NewFileUpdater(s *discordgo.Session, db *sql.DB, cfg *config.Config) *FileUpdater {
+func NewFileUpdater(s *discordgo.Session, db *sql.DB, cfg *config.Config, alerter *Alerter) *FileUpdater {
 	if cfg.Top10FilePath == "" {
 		log.Println("[DISCORD] TOP_10_FILE_PATH not set, 'FileUpdater' feature disabled")
 		return nil
 	}
 
-	return &FileUpdater{
+	tmpl, err := helpers.LoadTop10Template(cfg.Top10TemplatePath)
+	if err != nil {
+		log.Printf("[DISCORD] %v, 'FileUpdater' feature disabled", err)
+		return nil
+	}
+
+	for _, mapInfo := range cfg.AllowedMaps {
+		if mapInfo.Panel == nil {
+			log.Printf("[DISCORD] No in-game panel configured for %s, it will only be listed in the top players arrays", mapInfo.MapName)
+		}
+	}
+
+	fu := &FileUpdater{
 		updateInterval: cfg.UpdateInterval,
 		session:        s,
 		folderPath:     cfg.Top10FilePath,
 		db:             db,
 		allowedMaps:    cfg.AllowedMaps,
+		template:       tmpl,
+		reloadCommand:  strings.Fields(cfg.Top10ReloadCommand),
+		alerter:        alerter,
 	}
+
+	// An up to date file from a previous run doesn't need to be rewritten on start.
+	if content, err := os.ReadFile(cfg.Top10FilePath); err == nil {
+		fu.lastHash = sha256.Sum256(content)
+	}
+
+	return fu
 }
 
 func (sc *FileUpdater) Start() {
@@ -48,9 +85,65 @@ func (sc *FileUpdater) Start() {
 	}()
 }
 
+/*
+Regenerates the top 10 file and replaces it only when its content changed.
+*/
 func (sc *FileUpdater) updateFile() {
 	if sc == nil {
 		return // Service is disabled
 	}
-	helpers.UpdateTop10File(sc.folderPath, sc.db, sc.allowedMaps)
+
+	content, err := helpers.RenderTop10File(sc.template, sc.db, sc.allowedMaps)
+	if err != nil {
+		sc.reportFailure(err)
+		return
+	}
+
+	hash := sha256.Sum256(content)
+	if hash == sc.lastHash {
+		return
+	}
+
+	if err := helpers.WriteFileAtomic(sc.folderPath, content, 0644); err != nil {
+		sc.reportFailure(err)
+		return
+	}
+	sc.lastHash = hash
+	if sc.failing {
+		sc.failing = false
+		sc.alerter.Resolve("Top 10 file updated", fmt.Sprintf("`%s` is being written again.", sc.folderPath))
+	}
+
+	sc.runReloadCommand()
+}
+
+/*
+Alerts on the first failure only, so a persistent problem doesn't flood the alerts channel every tick.
+*/
+func (sc *FileUpdater) reportFailure(err error) {
+	if sc.failing {
+		log.Printf("[DISCORD] Top 10 file update still failing: %v", err)
+		return
+	}
+	sc.failing = true
+	sc.alerter.Alert("Top 10 file update failed", fmt.Sprintf("`%s` could not be updated: %v", sc.folderPath, err))
+}
+
+/*
+Runs the configured hook so the game server picks up the new file.
+*/
+func (sc *FileUpdater) runReloadCommand() {
+	if len(sc.reloadCommand) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reloadCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, sc.reloadCommand[0], sc.reloadCommand[1:]...).CombinedOutput()
+	if err != nil {
+		sc.alerter.Alert("Top 10 reload hook failed", fmt.Sprintf("`%s` failed: %v\n```\n%s\n```", strings.Join(sc.reloadCommand, " "), err, output))
+		return
+	}
+	log.Println("[DISCORD] Top 10 file changed, reload hook executed")
 }
@@ -19,9 +19,10 @@ type Leaderboard struct {
 	updateInterval time.Duration
 	allowedMaps    []config.MapInfo
 	guildID        string
+	roleSync       *RoleSync
 }
 
-func NewLeaderboardUpdater(session *discordgo.Session, db *sql.DB, cfg *config.Config) *Leaderboard {
+func NewLeaderboardUpdater(session *discordgo.Session, db *sql.DB, cfg *config.Config, roleSync *RoleSync) *Leaderboard {
 	if cfg.LeaderboardsChannelID == "" {
 		log.Println("[DISCORD] LEADERBOARDS_CHANNEL_ID not set, 'Leaderboards Updater' feature disabled")
 		return nil
@@ -33,6 +34,7 @@ func NewLeaderboardUpdater(session *discordgo.Session, db *sql.DB, cfg *config.C
 		updateInterval: cfg.UpdateInterval,
 		allowedMaps:    cfg.AllowedMaps,
 		guildID:        cfg.DiscordGuildID,
+		roleSync:       roleSync,
 	}
 }
 
@@ -74,4 +76,6 @@ func (lb *Leaderboard) updateLeaderboards() {
 			}
 		}
 	}
+
+	lb.roleSync.Sync()
 }
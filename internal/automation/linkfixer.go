@@ -1,89 +1,397 @@
 package automation
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/linkfix"
 )
 
-type fixPattern struct {
-	regex             *regexp.Regexp
-	replacement       string
-	captureGroupIndex int
+// How many replies are remembered to follow edits and deletions of their message.
+const maxTrackedReplies = 1000
+
+// The reaction the author of a message adds to the reply to delete it.
+const deleteReplyEmoji = "🗑️"
+
+/*
+The reply the bot sent with the fixed links of a message.
+A reply deleted by the author is kept without replyID so edits of the message don't bring it back.
+*/
+type fixReply struct {
+	channelID string
+	replyID   string
+	authorID  string
+	content   string
+	// Flags of the message before its embeds were suppressed, nil when they weren't.
+	originalFlags *discordgo.MessageFlags
 }
 
 type LinkFixer struct {
-	patterns []fixPattern
-}
-
-func NewLinkFixer() (*LinkFixer, error) {
-	patterns := []struct {
-		name              string
-		regexStr          string
-		replacement       string
-		captureGroupIndex int
-	}{
-		{
-			name:              "Twitter/X",
-			regexStr:          `https?://(?:www\.)?(twitter|x)\.com/(\w+/status/\d+)`,
-			replacement:       "https://fxtwitter.com/%s",
-			captureGroupIndex: 2,
-		},
-		{
-			name:              "Reddit",
-			regexStr:          `https?://(?:www\.)?reddit\.com(/r/\w+/comments/\w+[^?\s]*)`,
-			replacement:       "https://rxddit.com%s",
-			captureGroupIndex: 1,
-		},
-	}
-
-	var compiledPatterns []fixPattern
-	for _, p := range patterns {
-		regex, err := regexp.Compile(p.regexStr)
-		if err != nil {
-			return nil, fmt.Errorf("[DISCORD] failed to compile %s link fixer regex: %w", p.name, err)
-		}
-		compiledPatterns = append(compiledPatterns, fixPattern{
-			regex:             regex,
-			replacement:       p.replacement,
-			captureGroupIndex: p.captureGroupIndex,
-		})
+	db    *sql.DB
+	fixer *linkfix.Fixer
+
+	settingsMu sync.RWMutex
+	optOuts    map[string]bool
+	// Channel modes by channel ID, with any allowed channel the others get no replies.
+	channels map[string]string
+
+	// Only held to read and change the replies, never during requests to Discord.
+	repliesMu sync.Mutex
+	replies   map[string]*fixReply
+	// Message IDs in the order they were replied to, the oldest are forgotten first.
+	replyOrder []string
+}
+
+/*
+Creates a new LinkFixer service.
+The providers are stored in the database, the default ones are added on the first run.
+*/
+func NewLinkFixer(db *sql.DB) (*LinkFixer, error) {
+	seeded, err := helpers.SeedLinkProviders(db, linkfix.DefaultProviders())
+	if err != nil {
+		return nil, fmt.Errorf("failed to store link providers: %w", err)
+	}
+
+	lf := &LinkFixer{
+		db:      db,
+		fixer:   &linkfix.Fixer{},
+		replies: make(map[string]*fixReply),
+	}
+	if err := lf.ReloadProviders(); err != nil {
+		return nil, err
+	}
+	if err := lf.ReloadSettings(); err != nil {
+		return nil, err
+	}
+	if seeded {
+		log.Println("[DISCORD] Stored the default link providers, use /zlinkfix to change them")
+	}
+	return lf, nil
+}
+
+/*
+Reads the providers from the database again, new messages are fixed with them right away.
+*/
+func (lf *LinkFixer) ReloadProviders() error {
+	providers, err := helpers.LinkProvidersReader(lf.db)
+	if err != nil {
+		return err
+	}
+	if err := lf.fixer.SetProviders(providers); err != nil {
+		return fmt.Errorf("invalid link provider: %w", err)
+	}
+	return nil
+}
+
+/*
+Reads the users who turned link fixing off and the allowed and denied channels from the database again.
+*/
+func (lf *LinkFixer) ReloadSettings() error {
+	userIDs, err := helpers.LinkFixOptOutsReader(lf.db)
+	if err != nil {
+		return err
+	}
+	channels, err := helpers.LinkFixChannelsReader(lf.db)
+	if err != nil {
+		return err
+	}
+
+	optOuts := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		optOuts[userID] = true
 	}
+	lf.settingsMu.Lock()
+	lf.optOuts = optOuts
+	lf.channels = channels
+	lf.settingsMu.Unlock()
+	return nil
+}
 
-	return &LinkFixer{
-		patterns: compiledPatterns,
-	}, nil
+/*
+Returns the fixed links of the message, without replying to it.
+*/
+func (lf *LinkFixer) Test(content string) []string {
+	return lf.fixer.Fix(content)
 }
 
 /*
 Checks messages for links that need fixing and replies with a better version.
+The embeds of the original links are hidden when the bot can manage the messages of the channel.
 */
 func (lf *LinkFixer) MessageCreateHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.Author.Bot {
+	if m.Author == nil || m.Author.Bot || !lf.wanted(s, m.Message) {
+		return
+	}
+
+	fixedLinks := lf.fixer.Fix(m.Content)
+	if len(fixedLinks) == 0 {
+		return
+	}
+	lf.reply(s, m.Message, strings.Join(fixedLinks, "\n"))
+}
+
+/*
+Follows edits of messages: adds a reply when fixable links were added, changes it when they changed
+and deletes it when they were removed.
+*/
+func (lf *LinkFixer) MessageUpdateHandler(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Updates that only add embeds come without the author.
+	if m.Author == nil || m.Author.Bot {
 		return
 	}
 
-	var fixedLinks []string
-	for _, p := range lf.patterns {
-		matches := p.regex.FindAllStringSubmatch(m.Content, -1)
-		for _, match := range matches {
-			if len(match) > p.captureGroupIndex {
-				path := match[p.captureGroupIndex]
-				fixedLinks = append(fixedLinks, fmt.Sprintf(p.replacement, path))
+	replyContent := strings.Join(lf.fixer.Fix(m.Content), "\n")
+
+	lf.repliesMu.Lock()
+	previous, ok := lf.replies[m.ID]
+	var current fixReply
+	if ok {
+		current = *previous
+	}
+	lf.repliesMu.Unlock()
+
+	switch {
+	case ok && current.replyID == "":
+		return
+	case !ok && (replyContent == "" || !lf.wanted(s, m.Message)):
+		return
+	case !ok:
+		lf.reply(s, m.Message, replyContent)
+	case replyContent == "":
+		if reply := lf.take(m.ID); reply != nil {
+			deleteReplyMessage(s, m.ID, reply)
+			restoreEmbeds(s, m.ID, reply)
+		}
+	case replyContent != current.content:
+		// Suppressing the embeds is an update too, it comes back with the same links.
+		_, err := s.ChannelMessageEdit(current.channelID, current.replyID, replyContent)
+
+		lf.repliesMu.Lock()
+		defer lf.repliesMu.Unlock()
+		if lf.replies[m.ID] != previous {
+			return // Deleted or dismissed meanwhile.
+		}
+		if err != nil {
+			log.Printf("[DISCORD] Failed to update fixed link reply for message %s: %v", m.ID, err)
+			var restErr *discordgo.RESTError
+			if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage {
+				lf.forget(m.ID) // Deleted by someone, the next edit replies again.
 			}
+			return
 		}
+		previous.content = replyContent
 	}
+}
 
-	if len(fixedLinks) == 0 {
+/*
+Deletes the reply of a deleted message.
+*/
+func (lf *LinkFixer) MessageDeleteHandler(s *discordgo.Session, m *discordgo.MessageDelete) {
+	if reply := lf.take(m.ID); reply != nil {
+		deleteReplyMessage(s, m.ID, reply)
+	}
+}
+
+/*
+Deletes the replies of messages deleted together, e.g. by the spam guard.
+*/
+func (lf *LinkFixer) MessageDeleteBulkHandler(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	for _, messageID := range m.Messages {
+		if reply := lf.take(messageID); reply != nil {
+			deleteReplyMessage(s, messageID, reply)
+		}
+	}
+}
+
+/*
+Deletes a reply when the author of the fixed message reacts to it with the delete emoji.
+Only the replies still remembered can be deleted this way.
+*/
+func (lf *LinkFixer) MessageReactionAddHandler(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.Emoji.Name != deleteReplyEmoji || r.UserID == s.State.User.ID {
+		return
+	}
+
+	lf.repliesMu.Lock()
+	var messageID string
+	var dismissed *fixReply
+	for id, reply := range lf.replies {
+		if reply.replyID != r.MessageID {
+			continue
+		}
+		if reply.authorID == r.UserID {
+			copied := *reply
+			messageID, dismissed = id, &copied
+			lf.remember(id, &fixReply{channelID: reply.channelID, authorID: reply.authorID})
+		}
+		break
+	}
+	lf.repliesMu.Unlock()
+
+	if dismissed == nil {
 		return
 	}
+	deleteReplyMessage(s, messageID, dismissed)
+	restoreEmbeds(s, messageID, dismissed)
+	log.Printf("[DISCORD] Fixed link reply for message %s deleted by its author %s", messageID, r.UserID)
+}
 
-	replyContent := strings.Join(fixedLinks, "\n")
-	_, err := s.ChannelMessageSendReply(m.ChannelID, replyContent, m.Reference())
+/*
+Returns whether the author and the channel of the message want fixed links.
+Threads follow the list of their parent channel.
+*/
+func (lf *LinkFixer) wanted(s *discordgo.Session, m *discordgo.Message) bool {
+	lf.settingsMu.RLock()
+	defer lf.settingsMu.RUnlock()
+
+	if lf.optOuts[m.Author.ID] {
+		return false
+	}
+	if len(lf.channels) == 0 {
+		return true
+	}
+
+	mode, listed := lf.channels[m.ChannelID]
+	if !listed {
+		if channel, err := s.State.Channel(m.ChannelID); err == nil && channel.IsThread() {
+			mode, listed = lf.channels[channel.ParentID]
+		}
+	}
+	if listed {
+		return mode == helpers.LinkFixChannelAllow
+	}
+	for _, mode := range lf.channels {
+		if mode == helpers.LinkFixChannelAllow {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Replies to the message with its fixed links and hides its own embeds.
+The reply is sent without holding repliesMu, it is deleted again when another reply to the message was remembered meanwhile.
+*/
+func (lf *LinkFixer) reply(s *discordgo.Session, m *discordgo.Message, replyContent string) {
+	reply, err := s.ChannelMessageSendReply(m.ChannelID, replyContent, m.Reference())
 	if err != nil {
 		log.Printf("[DISCORD] Failed to send fixed link reply for message %s: %v", m.ID, err)
+		return
+	}
+	fixed := &fixReply{channelID: m.ChannelID, replyID: reply.ID, authorID: m.Author.ID, content: replyContent}
+
+	suppress := m.Flags&discordgo.MessageFlagsSuppressEmbeds == 0
+	if suppress {
+		permissions, err := s.State.UserChannelPermissions(s.State.User.ID, m.ChannelID)
+		suppress = err == nil && permissions&discordgo.PermissionManageMessages != 0
+	}
+	if suppress {
+		// Set before the edit so a reply deleted meanwhile still restores the embeds.
+		originalFlags := m.Flags
+		fixed.originalFlags = &originalFlags
+	}
+
+	// The creation and a quick edit of the message can both get here.
+	lf.repliesMu.Lock()
+	_, replied := lf.replies[m.ID]
+	if !replied {
+		lf.remember(m.ID, fixed)
+	}
+	lf.repliesMu.Unlock()
+
+	if replied {
+		deleteReplyMessage(s, m.ID, fixed)
+		return
+	}
+
+	if err := s.MessageReactionAdd(m.ChannelID, reply.ID, deleteReplyEmoji); err != nil {
+		log.Printf("[DISCORD] Failed to add delete reaction to fixed link reply for message %s: %v", m.ID, err)
+	}
+
+	if !suppress {
+		return
+	}
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      m.ID,
+		Channel: m.ChannelID,
+		Flags:   m.Flags | discordgo.MessageFlagsSuppressEmbeds,
+	})
+	if err == nil {
+		return
+	}
+	log.Printf("[DISCORD] Failed to suppress embeds of message %s: %v", m.ID, err)
+
+	lf.repliesMu.Lock()
+	fixed.originalFlags = nil
+	lf.repliesMu.Unlock()
+}
+
+/*
+Forgets the reply to the message, returning a copy of it, or nil when there is no reply to delete.
+*/
+func (lf *LinkFixer) take(messageID string) *fixReply {
+	lf.repliesMu.Lock()
+	defer lf.repliesMu.Unlock()
+
+	reply, ok := lf.replies[messageID]
+	if !ok {
+		return nil
+	}
+	lf.forget(messageID)
+	if reply.replyID == "" {
+		return nil
+	}
+	copied := *reply
+	return &copied
+}
+
+func deleteReplyMessage(s *discordgo.Session, messageID string, reply *fixReply) {
+	err := s.ChannelMessageDelete(reply.channelID, reply.replyID)
+	var restErr *discordgo.RESTError
+	if err != nil && !(errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage) {
+		log.Printf("[DISCORD] Failed to delete fixed link reply for message %s: %v", messageID, err)
+	}
+}
+
+/*
+Shows the embeds the bot suppressed on the message again.
+*/
+func restoreEmbeds(s *discordgo.Session, messageID string, reply *fixReply) {
+	if reply.originalFlags == nil {
+		return
+	}
+	// MessageEdit omits zero flags, the suppression couldn't be lifted from a message without other flags.
+	_, err := s.RequestWithBucketID("PATCH", discordgo.EndpointChannelMessage(reply.channelID, messageID),
+		map[string]discordgo.MessageFlags{"flags": *reply.originalFlags}, discordgo.EndpointChannelMessage(reply.channelID, ""))
+	if err != nil {
+		log.Printf("[DISCORD] Failed to restore embeds of message %s: %v", messageID, err)
+	}
+}
+
+func (lf *LinkFixer) remember(messageID string, reply *fixReply) {
+	if _, ok := lf.replies[messageID]; !ok {
+		lf.replyOrder = append(lf.replyOrder, messageID)
+	}
+	lf.replies[messageID] = reply
+
+	for len(lf.replyOrder) > maxTrackedReplies {
+		delete(lf.replies, lf.replyOrder[0])
+		lf.replyOrder = lf.replyOrder[1:]
+	}
+}
+
+func (lf *LinkFixer) forget(messageID string) {
+	delete(lf.replies, messageID)
+	for n, id := range lf.replyOrder {
+		if id == messageID {
+			lf.replyOrder = append(lf.replyOrder[:n], lf.replyOrder[n+1:]...)
+			break
+		}
 	}
 }
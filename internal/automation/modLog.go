@@ -0,0 +1,162 @@
+package automation
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/commands"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+/*
+Records every moderation action as a numbered case and posts it to the mod log channel.
+Actions of the bot are recorded by the services taking them, actions of moderators are picked up from the audit log.
+*/
+type ModLog struct {
+	session   *discordgo.Session
+	db        *sql.DB
+	channelID string
+}
+
+/*
+Creates a new ModLog service.
+Cases are always stored, they are only posted when MOD_LOG_CHANNEL_ID is set.
+*/
+func NewModLog(s *discordgo.Session, db *sql.DB, cfg *config.Config) *ModLog {
+	if cfg.ModLogChannelID == "" {
+		log.Println("[DISCORD] MOD_LOG_CHANNEL_ID not set, moderation cases will only be stored")
+	}
+	return &ModLog{
+		session:   s,
+		db:        db,
+		channelID: cfg.ModLogChannelID,
+	}
+}
+
+/*
+Stores the case, numbering it, and posts it to the mod log channel.
+*/
+func (ml *ModLog) Record(modCase *helpers.ModCase) (int64, error) {
+	id, err := ml.Open(modCase)
+	if err != nil {
+		return 0, err
+	}
+	ml.Publish(modCase)
+	return id, nil
+}
+
+/*
+Stores the case without posting it yet, for actions that need the case number before they are taken.
+*/
+func (ml *ModLog) Open(modCase *helpers.ModCase) (int64, error) {
+	if modCase.CreatedAt.IsZero() {
+		modCase.CreatedAt = time.Now()
+	}
+	id, err := helpers.CreateModCase(ml.db, modCase)
+	if err != nil {
+		// The action happens anyway, mods still get to see it.
+		ml.Post(&discordgo.MessageEmbed{
+			Title:       "Unrecorded " + modCase.Action,
+			Description: fmt.Sprintf("<@%s> (%s): %v", modCase.UserID, modCase.Username, err),
+			Color:       0xff0000,
+		})
+		return 0, err
+	}
+	modCase.ID = id
+	return id, nil
+}
+
+/*
+Posts an opened case to the mod log channel, with its history so far.
+*/
+func (ml *ModLog) Publish(modCase *helpers.ModCase) {
+	if modCase.Error != "" {
+		helpers.SetModCaseError(ml.db, modCase.ID, modCase.Error)
+	}
+	ml.Post(commands.ModCaseEmbed(modCase, helpers.CaseEventsReader(ml.db, modCase.ID)))
+}
+
+/*
+Sends an embed to the mod log channel, if there is one.
+*/
+func (ml *ModLog) Post(embed *discordgo.MessageEmbed) {
+	if ml.channelID == "" {
+		return
+	}
+	if _, err := ml.session.ChannelMessageSendEmbed(ml.channelID, embed); err != nil {
+		log.Printf("[DISCORD] Failed to post to the mod log channel: %v", err)
+	}
+}
+
+/*
+Records the bans, kicks and timeouts moderators make through Discord.
+The bot's own entries are skipped, their cases are recorded when it acts.
+*/
+func (ml *ModLog) AuditLogHandler(s *discordgo.Session, e *discordgo.GuildAuditLogEntryCreate) {
+	if e.AuditLogEntry == nil || e.ActionType == nil || e.UserID == s.State.User.ID {
+		return
+	}
+
+	modCase := &helpers.ModCase{
+		UserID:      e.TargetID,
+		ModeratorID: e.UserID,
+		Reason:      e.Reason,
+	}
+	switch *e.ActionType {
+	case discordgo.AuditLogActionMemberBanAdd:
+		modCase.Action = "ban"
+	case discordgo.AuditLogActionMemberBanRemove:
+		modCase.Action = "unban"
+	case discordgo.AuditLogActionMemberKick:
+		modCase.Action = "kick"
+	case discordgo.AuditLogActionMemberUpdate:
+		until, changed := timeoutChange(e.AuditLogEntry)
+		if !changed {
+			return
+		}
+		if until.IsZero() {
+			modCase.Action = "timeout lifted"
+		} else {
+			modCase.Action = "timeout"
+			modCase.Duration = time.Until(until).Round(time.Minute)
+		}
+	default:
+		return
+	}
+
+	modCase.Username = e.TargetID
+	if user, err := s.User(e.TargetID); err == nil {
+		modCase.Username = user.Username
+	}
+
+	if _, err := ml.Record(modCase); err == nil {
+		log.Printf("[DISCORD] Recorded %s of %s (%s) by moderator %s", modCase.Action, modCase.Username, modCase.UserID, modCase.ModeratorID)
+	}
+}
+
+/*
+Returns when the timeout set by a member update ends, zero when the timeout was lifted.
+changed is false when the update didn't touch the timeout.
+*/
+func timeoutChange(entry *discordgo.AuditLogEntry) (until time.Time, changed bool) {
+	for _, change := range entry.Changes {
+		if change.Key == nil || *change.Key != discordgo.AuditLogChangeKeyCommunicationDisabledUntil {
+			continue
+		}
+		value, ok := change.NewValue.(string)
+		if !ok {
+			return time.Time{}, true
+		}
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Printf("[DISCORD] Invalid timeout %q in audit log entry %s: %v", value, entry.ID, err)
+			return time.Time{}, false
+		}
+		return until, true
+	}
+	return time.Time{}, false
+}
@@ -64,12 +64,13 @@ func (sc *NewRunners) insertRunsInBatch(mapName string, runs []helpers.NewRunEnt
 	}
 	defer tx.Rollback() // Rollback on any error
 
-	stmt, err := tx.Prepare(`INSERT INTO "` + mapName + `" (player_name, time_score) VALUES (?, ?)`)
+	stmt, err := tx.Prepare(`INSERT INTO "` + mapName + `" (player_name, time_score, created_at) VALUES (?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
+	createdAt := time.Now().Unix()
 	for _, run := range runs {
 		timeInt, err := strconv.Atoi(run.TimeScore)
 		if err != nil {
@@ -77,7 +78,7 @@ func (sc *NewRunners) insertRunsInBatch(mapName string, runs []helpers.NewRunEnt
 			continue // Skip this run, but continue with others in the batch
 		}
 
-		if _, err := stmt.Exec(run.PlayerName, timeInt); err != nil {
+		if _, err := stmt.Exec(run.PlayerName, timeInt, createdAt); err != nil {
 			// The defer tx.Rollback() will handle this
 			return err
 		}
@@ -129,6 +130,12 @@ func (sc *NewRunners) updateNewRunners() {
 			continue
 		}
 
+		if helpers.IsValidTable(mapName, sc.allowedMaps) {
+			if err := helpers.UpdateWRHistory(sc.db, mapName, sc.allowedMaps); err != nil {
+				log.Printf("[DISCORD] Failed to update WR history for map %s: %v", mapName, err)
+			}
+		}
+
 		for len(newEntries) > 10 {
 			_, err = sc.session.ChannelMessageSend(sc.channelID, helpers.NewRunTable(newEntries[:10]))
 			if err != nil {
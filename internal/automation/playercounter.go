@@ -1,12 +1,15 @@
 package automation
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -14,35 +17,104 @@ import (
 	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
 )
 
+const serverStatusTitle = "Server Status"
+
 type PlayerCounter struct {
-	session        *discordgo.Session
-	channelID      string
-	lastKnownName  string
-	serverListURL  string
-	gamePath       string
-	updateInterval time.Duration
+	session         *discordgo.Session
+	db              *sql.DB
+	servers         []watchedServer
+	localServer     *regexp.Regexp
+	statusChannelID string
+	statusMessageID string
+	lastStatus      string
+	lastKnownNames  map[string]string // Voice channel ID -> current name
+	serverListURL   string
+	watchdog        *ServerWatchdog
+	updateInterval  time.Duration
+}
+
+type watchedServer struct {
+	config.WatchedServer
+	pattern *regexp.Regexp
+}
+
+/*
+Server as returned by the R5R server list.
+*/
+type listedServer struct {
+	Name        string  `json:"name"`
+	Map         string  `json:"map"`
+	Playlist    string  `json:"playlist"`
+	PlayerCount flexInt `json:"playerCount"`
+	MaxPlayers  flexInt `json:"maxPlayers"`
+}
+
+type serverList struct {
+	Servers []listedServer `json:"servers"`
+}
+
+/*
+Integer that the server list may send either as a number or as a string.
+*/
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*n = 0
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid number %s: %w", data, err)
+	}
+	*n = flexInt(parsed)
+	return nil
 }
 
 /*
 Creates a new PlayerCounter service.
 It returns nil if the feature is not configured.
 */
-func NewPlayerCounter(s *discordgo.Session, cfg *config.Config) *PlayerCounter {
-	if cfg.PlayerCountChannelID == "" {
-		log.Println("[DISCORD] PLAYER_COUNT_CHANNEL_ID not set, 'Player Counter' feature disabled")
+func NewPlayerCounter(s *discordgo.Session, db *sql.DB, cfg *config.Config, watchdog *ServerWatchdog) *PlayerCounter {
+	if len(cfg.WatchedServers) == 0 {
+		log.Println("[DISCORD] WATCHED_SERVERS and PLAYER_COUNT_CHANNEL_ID not set, 'Player Counter' feature disabled")
 		return nil
 	}
-	return &PlayerCounter{
-		session:        s,
-		channelID:      cfg.PlayerCountChannelID,
-		serverListURL:  cfg.R5RServerListURL,
-		updateInterval: cfg.UpdateInterval,
-		gamePath:       cfg.GamePath,
+
+	pc := &PlayerCounter{
+		session:         s,
+		db:              db,
+		localServer:     serverNamePattern(cfg.LocalServerName),
+		statusChannelID: cfg.ServerStatusChannelID,
+		lastKnownNames:  make(map[string]string),
+		serverListURL:   cfg.R5RServerListURL,
+		updateInterval:  cfg.UpdateInterval,
+		watchdog:        watchdog,
 	}
+	for _, server := range cfg.WatchedServers {
+		pc.servers = append(pc.servers, watchedServer{
+			WatchedServer: server,
+			pattern:       serverNamePattern(server.Pattern),
+		})
+	}
+	return pc
 }
 
 /*
-Starts the periodic update of the channel name.
+Turns a server name pattern into a case insensitive regexp where * matches any text.
+Everything else is literal, server names are full of brackets.
+*/
+func serverNamePattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for n, part := range parts {
+		parts[n] = regexp.QuoteMeta(strings.TrimSpace(part))
+	}
+	return regexp.MustCompile(`(?i)^\s*` + strings.Join(parts, ".*") + `\s*$`)
+}
+
+/*
+Starts the periodic update of the channel names and status message.
 */
 func (pc *PlayerCounter) Start() {
 	if pc == nil {
@@ -50,26 +122,32 @@ func (pc *PlayerCounter) Start() {
 	}
 	log.Println("[DISCORD] Starting 'Player Counter'...")
 
-	channel, err := pc.session.Channel(pc.channelID)
-	if err == nil {
-		pc.lastKnownName = channel.Name
+	for _, server := range pc.servers {
+		if server.ChannelID == "" {
+			continue
+		}
+		channel, err := pc.session.Channel(server.ChannelID)
+		if err == nil {
+			pc.lastKnownNames[server.ChannelID] = channel.Name
+		}
 	}
+	pc.findStatusMessage()
 
 	ticker := time.NewTicker(pc.updateInterval)
 	go func() {
 		// Perform an initial update on start
-		pc.updateChannelName()
+		pc.update()
 		for range ticker.C {
-			pc.updateChannelName()
+			pc.update()
 		}
 	}()
 }
 
-func (pc *PlayerCounter) getPlayerCount() (int, error) {
+func (pc *PlayerCounter) getServers() ([]listedServer, error) {
 
 	req, err := http.NewRequest("POST", pc.serverListURL, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w while 'Player Counter' execution", err)
+		return nil, fmt.Errorf("failed to create request: %w while 'Player Counter' execution", err)
 	}
 	req.Header.Set("User-Agent", "GoldenSaplingBotTreeree/1.0")
 	req.Header.Set("Accept", "*/*")
@@ -80,68 +158,192 @@ func (pc *PlayerCounter) getPlayerCount() (int, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("request failed: %w while 'Player Counter' execution", err)
+		return nil, fmt.Errorf("request failed: %w while 'Player Counter' execution", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("received non-200 response code: %d while 'Player Counter' execution", resp.StatusCode)
+		return nil, fmt.Errorf("received non-200 response code: %d while 'Player Counter' execution", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %w while 'Player Counter' execution", err)
+	var result serverList
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w while 'Player Counter' execution", err)
 	}
+	return result.Servers, nil
+}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, fmt.Errorf("failed to parse JSON response: %w while 'Player Counter' execution", err)
+/*
+Fetches the server list, then records the player counts and updates the voice channels and the status message.
+Several listed servers matching the same pattern are added up.
+Whether the local server is listed is passed on to the watchdog.
+*/
+func (pc *PlayerCounter) update() {
+	servers, err := pc.getServers()
+	if err != nil {
+		log.Printf("[DISCORD] Failed to get the server list while 'Player Counter' execution... %v", err)
+	} else {
+		pc.watchdog.ReportListed(anyServerMatches(servers, pc.localServer))
 	}
 
-	servers := result["servers"].([]interface{})
-	for _, server := range servers {
-		srv := server.(map[string]interface{})
-		if srv["name"] == "[NA] MOVEMENT HUB" {
-			playerCountStr, ok := srv["playerCount"].(string)
-			if !ok {
-				return 0, fmt.Errorf("playerCount is not a string while 'Player Counter' execution")
+	matches := make([][]listedServer, len(pc.servers))
+	for n, server := range pc.servers {
+		for _, listed := range servers {
+			if server.pattern.MatchString(listed.Name) {
+				matches[n] = append(matches[n], listed)
 			}
-			playersInt, err := strconv.Atoi(playerCountStr)
-			if err != nil {
-				return 0, fmt.Errorf("failed to convert player count to integer: %w while 'Player Counter' execution", err)
-			}
-			return playersInt, nil
+		}
+		if server.ChannelID != "" {
+			pc.updateChannelName(server, matches[n])
+		}
+		// Without the list there is no way to tell an empty server from an unreachable one.
+		if err == nil {
+			players, maxPlayers := totalPlayers(matches[n])
+			helpers.RecordPlayerCount(pc.db, server.Pattern, players, maxPlayers, time.Now())
 		}
 	}
 
-	err = helpers.RestartHUB(pc.gamePath)
-	if err != nil {
-		return 0, fmt.Errorf("bot tried to restart HUB server but got: %s", err)
+	pc.updateStatusMessage(matches, err == nil)
+}
+
+func anyServerMatches(servers []listedServer, pattern *regexp.Regexp) bool {
+	for _, server := range servers {
+		if pattern.MatchString(server.Name) {
+			return true
+		}
 	}
-	return 0, fmt.Errorf("bot restarted HUB successfully")
+	return false
 }
 
 /*
-Fetches the player count and updates the Discord channel name if it has changed.
+Renames the voice channel of a watched server if its player count changed.
 */
-func (pc *PlayerCounter) updateChannelName() {
+func (pc *PlayerCounter) updateChannelName(server watchedServer, listed []listedServer) {
 	var channelName string
-	count, err := pc.getPlayerCount()
-	if err != nil {
-		log.Printf("[DISCORD] Failed to get players count while 'Player Counter' execution... %v", err)
-		channelName = "HUB is offline"
+	if len(listed) == 0 {
+		channelName = fmt.Sprintf("%s: offline", server.Label)
 	} else {
-		channelName = fmt.Sprintf("Players online: %d", count)
+		players, maxPlayers := totalPlayers(listed)
+		channelName = fmt.Sprintf("%s: %d/%d", server.Label, players, maxPlayers)
 	}
 
-	if channelName == pc.lastKnownName {
+	if channelName == pc.lastKnownNames[server.ChannelID] {
 		return
 	}
 
-	_, err = pc.session.ChannelEdit(pc.channelID, &discordgo.ChannelEdit{Name: channelName})
+	_, err := pc.session.ChannelEdit(server.ChannelID, &discordgo.ChannelEdit{Name: channelName})
 	if err != nil {
-		log.Printf("[DISCORD] Failed to update hub status voice channel while 'Player Counter' execution: %v", err)
+		log.Printf("[DISCORD] Failed to update %s status voice channel while 'Player Counter' execution: %v", server.Pattern, err)
 	} else {
-		pc.lastKnownName = channelName
+		pc.lastKnownNames[server.ChannelID] = channelName
+	}
+}
+
+func totalPlayers(listed []listedServer) (players, maxPlayers int) {
+	for _, srv := range listed {
+		players += int(srv.PlayerCount)
+		maxPlayers += int(srv.MaxPlayers)
+	}
+	return players, maxPlayers
+}
+
+/*
+Reuses the status message posted by a previous run instead of posting a new one.
+*/
+func (pc *PlayerCounter) findStatusMessage() {
+	if pc.statusChannelID == "" {
+		return
+	}
+
+	messages, err := pc.session.ChannelMessages(pc.statusChannelID, 50, "", "", "")
+	if err != nil {
+		log.Printf("[DISCORD] Failed to fetch server status channel messages: %v", err)
+		return
+	}
+	for _, message := range messages {
+		if message.Author == nil || message.Author.ID != pc.session.State.User.ID {
+			continue
+		}
+		if len(message.Embeds) > 0 && message.Embeds[0].Title == serverStatusTitle {
+			pc.statusMessageID = message.ID
+			return
+		}
+	}
+}
+
+/*
+Edits the combined status message with every watched server, posting it if it doesn't exist yet.
+*/
+func (pc *PlayerCounter) updateStatusMessage(matches [][]listedServer, listReachable bool) {
+	if pc.statusChannelID == "" {
+		return
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	online := 0
+	for n, server := range pc.servers {
+		if len(matches[n]) == 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   server.Pattern,
+				Value:  "Offline",
+				Inline: true,
+			})
+			continue
+		}
+		online++
+		for _, listed := range matches[n] {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   listed.Name,
+				Value:  fmt.Sprintf("Players: %d/%d\nMap: %s\nPlaylist: %s", listed.PlayerCount, listed.MaxPlayers, listed.Map, listed.Playlist),
+				Inline: true,
+			})
+		}
+	}
+	// Embeds are limited to 25 fields.
+	if len(fields) > 25 {
+		fields = fields[:25]
+	}
+
+	color := 0x00ff00
+	description := fmt.Sprintf("%d/%d watched servers online", online, len(pc.servers))
+	switch {
+	case !listReachable:
+		color = 0xff0000
+		description = "The server list could not be reached"
+	case online == 0:
+		color = 0xff0000
+	case online < len(pc.servers):
+		color = 0xffa600
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       serverStatusTitle,
+		Description: description,
+		Color:       color,
+		Fields:      fields,
+	}
+
+	// Only edits when something changed, the timestamp shows when that was.
+	content, _ := json.Marshal(embed)
+	if string(content) == pc.lastStatus && pc.statusMessageID != "" {
+		return
+	}
+	embed.Timestamp = time.Now().Format(time.RFC3339)
+
+	if pc.statusMessageID == "" {
+		message, err := pc.session.ChannelMessageSendEmbed(pc.statusChannelID, embed)
+		if err != nil {
+			log.Printf("[DISCORD] Failed to post server status message while 'Player Counter' execution: %v", err)
+			return
+		}
+		pc.statusMessageID = message.ID
+	} else if _, err := pc.session.ChannelMessageEditEmbed(pc.statusChannelID, pc.statusMessageID, embed); err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage {
+			pc.statusMessageID = "" // Deleted by someone, a new one is posted next update.
+		}
+		log.Printf("[DISCORD] Failed to update server status message while 'Player Counter' execution: %v", err)
+		return
 	}
+	pc.lastStatus = string(content)
 }
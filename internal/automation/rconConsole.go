@@ -0,0 +1,114 @@
+package automation
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/rcon"
+)
+
+// How long a console command may take, including connecting and authenticating.
+const rconTimeout = 5 * time.Second
+
+/*
+Runs allowed console commands on the game server for admins and records every use in the audit channel.
+*/
+type RconConsole struct {
+	session        *discordgo.Session
+	address        string
+	password       string
+	key            []byte
+	allowed        []string
+	auditChannelID string
+}
+
+/*
+Creates a new RconConsole.
+It returns nil if the feature is not configured.
+*/
+func NewRconConsole(s *discordgo.Session, cfg *config.Config) (*RconConsole, error) {
+	if cfg.RconAddress == "" {
+		log.Println("[DISCORD] RCON_ADDRESS not set, 'RCON Console' feature disabled")
+		return nil, nil
+	}
+	key, err := rcon.ParseKey(cfg.RconKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RconAuditChannelID == "" {
+		log.Println("[DISCORD] RCON_AUDIT_CHANNEL_ID not set, RCON commands will only be logged")
+	}
+	return &RconConsole{
+		session:        s,
+		address:        cfg.RconAddress,
+		password:       cfg.RconPassword,
+		key:            key,
+		allowed:        cfg.RconAllowedCommands,
+		auditChannelID: cfg.RconAuditChannelID,
+	}, nil
+}
+
+/*
+Runs a console command line on behalf of a Discord user and returns its output.
+Refused commands are audited too.
+*/
+func (rc *RconConsole) Run(userID, line string) (string, error) {
+	if err := rcon.CheckAllowed(line, rc.allowed); err != nil {
+		rc.audit(userID, line, "", err)
+		return "", err
+	}
+
+	client, err := rcon.Dial(rc.address, rc.password, rc.key, rconTimeout)
+	if err != nil {
+		rc.audit(userID, line, "", err)
+		return "", err
+	}
+	defer client.Close()
+
+	output, err := client.Exec(line)
+	rc.audit(userID, line, output, err)
+	return output, err
+}
+
+func (rc *RconConsole) audit(userID, line, output string, err error) {
+	result := "Executed"
+	color := 0x00ff00
+	if err != nil {
+		result = fmt.Sprintf("Failed: %v", err)
+		color = 0xff0000
+	}
+	log.Printf("[DISCORD] RCON command %q by %s: %s", line, userID, result)
+
+	if rc.auditChannelID == "" {
+		return
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "RCON Command",
+		Description: fmt.Sprintf("<@%s> ran `%s`", userID, line),
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Result", Value: result},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if output != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Output", Value: codeBlock(output, 1000)})
+	}
+	if _, err := rc.session.ChannelMessageSendEmbed(rc.auditChannelID, embed); err != nil {
+		log.Printf("[DISCORD] Failed to send RCON audit message: %v", err)
+	}
+}
+
+/*
+Wraps the end of a text in a code block of at most limit characters of content.
+*/
+func codeBlock(text string, limit int) string {
+	if len(text) > limit {
+		text = "..." + text[len(text)-limit:]
+	}
+	return "```\n" + strings.ReplaceAll(text, "```", "'''") + "\n```"
+}
@@ -0,0 +1,154 @@
+package automation
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+// Pause between role changes so a big leaderboard shake-up doesn't burst through the rate limit.
+const roleSyncDelay = 500 * time.Millisecond
+
+/*
+Mirrors leaderboard placements as Discord roles for players with a linked account.
+*/
+type RoleSync struct {
+	session     *discordgo.Session
+	db          *sql.DB
+	guildID     string
+	roles       []config.RankRole
+	allowedMaps []config.MapInfo
+
+	mu      sync.Mutex
+	applied map[string]string // Discord ID -> role ID last synced, "" for none.
+}
+
+/*
+Creates a new RoleSync service.
+It returns nil if no leaderboard roles are configured.
+*/
+func NewRoleSync(s *discordgo.Session, db *sql.DB, cfg *config.Config) *RoleSync {
+	if len(cfg.LeaderboardRoles) == 0 {
+		log.Println("[DISCORD] LEADERBOARD_ROLES not set, 'Role Sync' feature disabled")
+		return nil
+	}
+	return &RoleSync{
+		session:     s,
+		db:          db,
+		guildID:     cfg.DiscordGuildID,
+		roles:       cfg.LeaderboardRoles,
+		allowedMaps: cfg.AllowedMaps,
+		applied:     make(map[string]string),
+	}
+}
+
+/*
+Assigns every linked player the role of their best placement across all maps and revokes the others.
+Members are only fetched and edited when their target role changed since the last sync, so repeated calls are cheap.
+*/
+func (rs *RoleSync) Sync() {
+	if rs == nil {
+		return // Service is disabled
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	// Roles are sorted by rank, the last one reaches the furthest down the leaderboards.
+	lastRank := rs.roles[len(rs.roles)-1].MaxRank
+
+	bestRanks := make(map[string]int)
+	for _, mapInfo := range rs.allowedMaps {
+		for _, entry := range helpers.LeaderboardRangeReader(rs.db, mapInfo.MapName, 1, lastRank, rs.allowedMaps) {
+			if best, ok := bestRanks[entry.PlayerName]; !ok || entry.Rank < best {
+				bestRanks[entry.PlayerName] = entry.Rank
+			}
+		}
+	}
+
+	for _, link := range helpers.PlayerLinksReader(rs.db) {
+		target := rs.roleForRank(bestRanks[link.PlayerName])
+		if applied, ok := rs.applied[link.DiscordID]; ok && applied == target {
+			continue
+		}
+
+		if err := rs.apply(link.DiscordID, target); err != nil {
+			var rateLimitErr *discordgo.RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				log.Printf("[DISCORD] Rate limited while syncing leaderboard roles, resuming next tick: %v", err)
+				return
+			}
+			log.Printf("[DISCORD] Failed to sync leaderboard roles for %s (%s): %v", link.PlayerName, link.DiscordID, err)
+			continue
+		}
+		rs.applied[link.DiscordID] = target
+	}
+}
+
+/*
+Removes every leaderboard role from the member, used when an account gets unlinked.
+*/
+func (rs *RoleSync) RevokeAll(discordID string) error {
+	if rs == nil {
+		return nil // Service is disabled
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	delete(rs.applied, discordID)
+	return rs.apply(discordID, "")
+}
+
+/*
+Returns the role earned by a leaderboard position, rank 0 meaning unranked.
+*/
+func (rs *RoleSync) roleForRank(rank int) string {
+	if rank == 0 {
+		return ""
+	}
+	for _, role := range rs.roles {
+		if rank <= role.MaxRank {
+			return role.RoleID
+		}
+	}
+	return ""
+}
+
+/*
+Makes the target role the only leaderboard role of the member, an empty target removes all of them.
+*/
+func (rs *RoleSync) apply(discordID, target string) error {
+	member, err := rs.session.GuildMember(rs.guildID, discordID)
+	if err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMember {
+			return nil // Member left the server, nothing to sync.
+		}
+		return err
+	}
+
+	for _, role := range rs.roles {
+		has := slices.Contains(member.Roles, role.RoleID)
+		want := role.RoleID == target
+		switch {
+		case want && !has:
+			err = rs.session.GuildMemberRoleAdd(rs.guildID, discordID, role.RoleID)
+		case !want && has:
+			err = rs.session.GuildMemberRoleRemove(rs.guildID, discordID, role.RoleID)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		time.Sleep(roleSyncDelay)
+	}
+
+	return nil
+}
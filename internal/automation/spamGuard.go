@@ -0,0 +1,149 @@
+package automation
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/moderation"
+)
+
+/*
+Times out users whose messages look like a scam wave and deletes their recent messages.
+Unlike AutoBan it doesn't look at the words, so rewording the scam doesn't get it through.
+*/
+type SpamGuard struct {
+	detector *moderation.SpamDetector
+	timeout  time.Duration
+	dryRun   bool
+	modLog   *ModLog
+	adminIDs []string
+}
+
+/*
+Creates a new SpamGuard service.
+It returns nil if every heuristic is disabled.
+*/
+func NewSpamGuard(cfg *config.Config, modLog *ModLog) *SpamGuard {
+	limits := moderation.SpamLimits{
+		DuplicateChannels: cfg.SpamDuplicateChannels,
+		DuplicateWindow:   cfg.SpamDuplicateWindow,
+		MaxMentions:       cfg.SpamMaxMentions,
+		NewAccountAge:     cfg.SpamNewAccountAge,
+	}
+	if limits.DuplicateChannels == 0 && limits.MaxMentions == 0 && limits.NewAccountAge == 0 {
+		log.Println("[DISCORD] Every SPAM_* heuristic set to 0, 'Spam Guard' feature disabled")
+		return nil
+	}
+
+	return &SpamGuard{
+		detector: moderation.NewSpamDetector(limits),
+		timeout:  min(cfg.SpamTimeout, maxTimeout),
+		dryRun:   cfg.ModDryRun,
+		modLog:   modLog,
+		adminIDs: cfg.AdminIDs,
+	}
+}
+
+func (sg *SpamGuard) MessageCreateHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if sg == nil {
+		return // Service is disabled
+	}
+	if m.Author == nil || m.Author.Bot || m.GuildID == "" {
+		return
+	}
+	if helpers.IsAdmin(m.Author.ID, sg.adminIDs) {
+		return
+	}
+
+	message := moderation.SpamMessage{
+		ID:        m.ID,
+		ChannelID: m.ChannelID,
+		AuthorID:  m.Author.ID,
+		Content:   m.Content,
+		Mentions:  len(m.Mentions) + len(m.MentionRoles),
+	}
+	if m.MentionEveryone {
+		message.Mentions++
+	}
+	if created, err := discordgo.SnowflakeTimestamp(m.Author.ID); err == nil {
+		message.AccountCreated = created
+	}
+	for _, attachment := range m.Attachments {
+		message.Attachments = append(message.Attachments, attachment.Filename)
+	}
+
+	verdict := sg.detector.Check(message, time.Now())
+	if verdict == nil {
+		return
+	}
+
+	if sg.dryRun {
+		if !verdict.AlreadyFlagged {
+			log.Printf("[DISCORD] Dry run: would time out %s (%s) for spam: %s", m.Author.Username, m.Author.ID, verdict.Reason)
+			sg.modLog.Post(&discordgo.MessageEmbed{
+				Title: fmt.Sprintf("Dry run: would timeout (%s)", helpers.FormatDuration(sg.timeout)),
+				Color: 0xffa600,
+				Fields: []*discordgo.MessageEmbedField{
+					{Name: "User", Value: fmt.Sprintf("<@%s> (%s)", m.Author.ID, m.Author.Username), Inline: true},
+					{Name: "Channel", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+					{Name: "Reason", Value: verdict.Reason},
+				},
+				Timestamp: time.Now().Format(time.RFC3339),
+			})
+		}
+		return
+	}
+
+	deleteMessages(s, verdict.Messages)
+	if verdict.AlreadyFlagged {
+		return
+	}
+
+	modCase := &helpers.ModCase{
+		UserID:    m.Author.ID,
+		Username:  m.Author.Username,
+		Action:    moderation.ActionTimeout.String(),
+		Duration:  sg.timeout,
+		Rule:      "Spam",
+		Reason:    verdict.Reason,
+		ChannelID: m.ChannelID,
+		Content:   m.Content,
+	}
+	for _, attachment := range m.Attachments {
+		modCase.Attachments = append(modCase.Attachments, attachment.URL)
+	}
+
+	until := time.Now().Add(sg.timeout)
+	if err := s.GuildMemberTimeout(m.GuildID, m.Author.ID, &until); err != nil {
+		log.Printf("[DISCORD] Failed to time out %s (%s) for spam: %v", m.Author.Username, m.Author.ID, err)
+		modCase.Error = err.Error()
+	} else {
+		log.Printf("[DISCORD] User %s (%s) timed out for spam: %s", m.Author.Username, m.Author.ID, verdict.Reason)
+	}
+	sg.modLog.Record(modCase)
+}
+
+/*
+Deletes messages grouped by channel, in bulk when there are several.
+*/
+func deleteMessages(s *discordgo.Session, messages map[string][]string) {
+	for channelID, messageIDs := range messages {
+		// Bulk deletes take at most 100 messages.
+		for start := 0; start < len(messageIDs); start += 100 {
+			batch := messageIDs[start:min(start+100, len(messageIDs))]
+			var err error
+			if len(batch) == 1 {
+				err = s.ChannelMessageDelete(channelID, batch[0])
+			} else {
+				err = s.ChannelMessagesBulkDelete(channelID, batch)
+			}
+			if err != nil {
+				log.Printf("[DISCORD] Failed to delete %d spam messages in channel %s: %v", len(batch), channelID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package automation
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/supervisor"
+)
+
+// How much of the server output is included in alerts.
+const alertOutputLength = 1500
+
+/*
+Checks the game server every update and restarts it through the supervisor following the restart policy.
+The server is healthy when its process runs and either its probes pass or, without probes, it is in the server list.
+*/
+type ServerWatchdog struct {
+	supervisor     supervisor.ServerSupervisor
+	probes         []supervisor.HealthProbe
+	alerter        *Alerter
+	updateInterval time.Duration
+
+	actionMu   sync.Mutex // Serializes starts and stops
+	mu         sync.Mutex // Guards everything below
+	policy     *supervisor.RestartPolicy
+	listed     *bool
+	listedAt   time.Time
+	lastProbes map[string]error
+	paused     bool
+	throttled  bool
+}
+
+/*
+Creates a new ServerWatchdog service.
+It returns nil if the game server is not supervised by the bot.
+*/
+func NewServerWatchdog(sup supervisor.ServerSupervisor, alerter *Alerter, cfg *config.Config) *ServerWatchdog {
+	if _, err := sup.Running(); errors.Is(err, supervisor.ErrNotSupervised) {
+		log.Println("[DISCORD] SERVER_SUPERVISOR not set, 'Server Watchdog' feature disabled")
+		return nil
+	}
+	return &ServerWatchdog{
+		supervisor:     sup,
+		probes:         supervisor.NewProbes(cfg),
+		alerter:        alerter,
+		updateInterval: cfg.UpdateInterval,
+		policy: &supervisor.RestartPolicy{
+			FailuresBeforeRestart: cfg.RestartAfter,
+			BaseBackoff:           cfg.RestartBackoff,
+			MaxRestartsPerHour:    cfg.MaxRestartsPerHour,
+		},
+	}
+}
+
+func (sw *ServerWatchdog) Start() {
+	if sw == nil {
+		return // Service is disabled
+	}
+	log.Printf("[DISCORD] Starting 'Server Watchdog' with the %s supervisor...", sw.supervisor.Name())
+
+	ticker := time.NewTicker(sw.updateInterval)
+	go func() {
+		for range ticker.C {
+			sw.check()
+		}
+	}()
+}
+
+/*
+Records whether the local server showed up in the server list.
+*/
+func (sw *ServerWatchdog) ReportListed(listed bool) {
+	if sw == nil {
+		return // Service is disabled
+	}
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.listed = &listed
+	sw.listedAt = time.Now()
+}
+
+/*
+Returns the listing result when it is recent enough to be trusted.
+Must be called with mu held.
+*/
+func (sw *ServerWatchdog) recentListing() *bool {
+	if sw.listed == nil || time.Since(sw.listedAt) > 3*sw.updateInterval {
+		return nil
+	}
+	return sw.listed
+}
+
+func (sw *ServerWatchdog) runProbes() map[string]error {
+	results := make(map[string]error, len(sw.probes))
+	for _, probe := range sw.probes {
+		results[probe.Name()] = probe.Check()
+	}
+	return results
+}
+
+/*
+Returns why the server is unhealthy, empty when it is fine.
+When there are probes the server list is only informative, so an outage of the master server
+doesn't get a healthy server restarted. Must be called with mu held.
+*/
+func (sw *ServerWatchdog) diagnose(running bool, probeResults map[string]error) string {
+	if !running {
+		return "the server process is not running"
+	}
+
+	listed := sw.recentListing()
+	if len(sw.probes) == 0 {
+		if listed != nil && !*listed {
+			return "the server is missing from the server list"
+		}
+		return ""
+	}
+
+	for _, probe := range sw.probes {
+		if err := probeResults[probe.Name()]; err != nil {
+			return fmt.Sprintf("the %s probe failed: %v", probe.Name(), err)
+		}
+	}
+	if listed != nil && !*listed {
+		log.Println("[DISCORD] Game server is missing from the server list but answers its probes, not restarting it")
+	}
+	return ""
+}
+
+func (sw *ServerWatchdog) check() {
+	sw.actionMu.Lock()
+	defer sw.actionMu.Unlock()
+
+	running, err := sw.supervisor.Running()
+	if err != nil {
+		log.Printf("[DISCORD] Failed to check the game server: %v", err)
+		return
+	}
+
+	probeResults := sw.runProbes()
+
+	sw.mu.Lock()
+	sw.lastProbes = probeResults
+	if sw.paused {
+		sw.mu.Unlock()
+		return
+	}
+	problem := sw.diagnose(running, probeResults)
+	now := time.Now()
+	decision := sw.policy.Observe(problem == "", now)
+	status := sw.policy.Status(now)
+	sw.mu.Unlock()
+
+	switch decision {
+	case supervisor.DecisionRecovered:
+		sw.mu.Lock()
+		sw.throttled = false
+		sw.mu.Unlock()
+		sw.alerter.Resolve("Game server recovered", "The game server is healthy again after the automatic restart.")
+	case supervisor.DecisionFailing:
+		log.Printf("[DISCORD] Game server unhealthy, %s (%d/%d failed checks)", problem, status.Failures, status.FailuresBeforeRestart)
+	case supervisor.DecisionThrottled:
+		sw.mu.Lock()
+		alreadyAlerted := sw.throttled
+		sw.throttled = true
+		sw.mu.Unlock()
+		if !alreadyAlerted {
+			sw.alerter.Alert("Game server flapping", fmt.Sprintf(
+				"The game server was restarted %d times in the last hour and is still unhealthy: %s.\nAutomatic restarts are paused until <t:%d:t>.\n%s",
+				status.RestartsLastHour, problem, status.NextRestart.Unix(), formatServerOutput(sw.supervisor.RecentOutput())))
+		}
+	case supervisor.DecisionRestart:
+		// The log is emptied when the server starts again.
+		output := sw.supervisor.RecentOutput()
+		err := sw.supervisor.Restart()
+
+		sw.mu.Lock()
+		sw.throttled = false
+		sw.policy.RecordRestart(now)
+		status = sw.policy.Status(now)
+		sw.mu.Unlock()
+
+		if err != nil {
+			sw.alerter.Alert("Game server restart failed", fmt.Sprintf("The game server is down (%s) and the %s supervisor failed to restart it: %v\n%s",
+				problem, sw.supervisor.Name(), err, formatServerOutput(output)))
+			return
+		}
+		sw.alerter.Alert("Game server restarted", fmt.Sprintf("The game server was restarted after %d failed checks, %s.\nRestart %d/%d this hour.\n%s",
+			status.FailuresBeforeRestart, problem, status.RestartsLastHour, status.MaxRestartsPerHour, formatServerOutput(output)))
+	}
+}
+
+/*
+Restarts the server on an admin's request and turns automatic restarts back on.
+*/
+func (sw *ServerWatchdog) Restart() error {
+	if sw == nil {
+		return supervisor.ErrNotSupervised
+	}
+	sw.actionMu.Lock()
+	defer sw.actionMu.Unlock()
+
+	err := sw.supervisor.Restart()
+	sw.mu.Lock()
+	sw.paused = false
+	sw.policy.Reset()
+	sw.mu.Unlock()
+	return err
+}
+
+/*
+Stops the server on an admin's request, automatic restarts stay off until the next manual restart.
+*/
+func (sw *ServerWatchdog) Stop() error {
+	if sw == nil {
+		return supervisor.ErrNotSupervised
+	}
+	sw.actionMu.Lock()
+	defer sw.actionMu.Unlock()
+
+	if err := sw.supervisor.Stop(); err != nil {
+		return err
+	}
+	sw.mu.Lock()
+	sw.paused = true
+	sw.policy.Reset()
+	sw.mu.Unlock()
+	return nil
+}
+
+func (sw *ServerWatchdog) Status() supervisor.Status {
+	if sw == nil {
+		return supervisor.Status{Supervisor: "none", RunningErr: supervisor.ErrNotSupervised}
+	}
+	// Probes are not run here, they may take longer than Discord waits for a response.
+	running, err := sw.supervisor.Running()
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return supervisor.Status{
+		Supervisor: sw.supervisor.Name(),
+		Running:    running,
+		RunningErr: err,
+		Listed:     sw.recentListing(),
+		Probes:     sw.lastProbes,
+		Paused:     sw.paused,
+		Policy:     sw.policy.Status(time.Now()),
+	}
+}
+
+/*
+Formats the end of the server output as a code block for alerts.
+*/
+func formatServerOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return "No server output available."
+	}
+	return "Recent server output:\n" + codeBlock(output, alertOutputLength)
+}
@@ -0,0 +1,110 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// Colors used by the renderers, picked to stay readable on Discord's dark theme.
+var (
+	Background = color.RGBA{R: 0x2b, G: 0x2d, B: 0x31, A: 0xff}
+	GridColor  = color.RGBA{R: 0x40, G: 0x44, B: 0x4b, A: 0xff}
+	TextColor  = color.RGBA{R: 0xdb, G: 0xde, B: 0xe1, A: 0xff}
+	Palette    = []color.RGBA{
+		{R: 0xff, G: 0xa6, B: 0x00, A: 0xff},
+		{R: 0x58, G: 0x65, B: 0xf2, A: 0xff},
+		{R: 0x57, G: 0xf2, B: 0x87, A: 0xff},
+		{R: 0xed, G: 0x42, B: 0x45, A: 0xff},
+		{R: 0xeb, G: 0x45, B: 0x9e, A: 0xff},
+		{R: 0xfe, G: 0xe7, B: 0x5c, A: 0xff},
+	}
+)
+
+// Closest emoji to each palette color, to build legends in Discord messages.
+var paletteEmojis = []string{"🟧", "🟦", "🟩", "🟥", "🟪", "🟨"}
+
+/*
+Returns the palette color for the n-th series, wrapping around when needed.
+*/
+func PaletteColor(n int) color.RGBA {
+	return Palette[n%len(Palette)]
+}
+
+/*
+Returns the emoji matching PaletteColor(n).
+*/
+func PaletteEmoji(n int) string {
+	return paletteEmojis[n%len(paletteEmojis)]
+}
+
+type canvas struct {
+	img *image.RGBA
+}
+
+func newCanvas(width, height int) *canvas {
+	c := &canvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.fillRect(0, 0, width, height, Background)
+	return c
+}
+
+func (c *canvas) set(x, y int, col color.RGBA) {
+	if !(image.Point{X: x, Y: y}.In(c.img.Rect)) {
+		return
+	}
+	c.img.SetRGBA(x, y, col)
+}
+
+func (c *canvas) fillRect(x0, y0, x1, y1 int, col color.RGBA) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			c.set(x, y, col)
+		}
+	}
+}
+
+/*
+Draws a square brush of the given thickness centered on the point.
+*/
+func (c *canvas) dot(x, y, thickness int, col color.RGBA) {
+	half := thickness / 2
+	c.fillRect(x-half, y-half, x-half+thickness, y-half+thickness, col)
+}
+
+/*
+Draws a line using Bresenham's algorithm.
+*/
+func (c *canvas) line(x0, y0, x1, y1, thickness int, col color.RGBA) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		c.dot(x0, y0, thickness, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,190 @@
+package chart
+
+import (
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+)
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+type Series struct {
+	Name   string
+	Points []Point
+	Color  color.RGBA
+	Step   bool // Connects points with a horizontal then vertical segment, like a record progression.
+	Dots   bool // Draws every point as a dot instead of linking them.
+}
+
+/*
+Value range covered by a chart axis.
+*/
+type bounds struct {
+	min float64
+	max float64
+}
+
+func (b bounds) scale(value float64, size int) float64 {
+	if b.max == b.min {
+		return float64(size) / 2
+	}
+	return (value - b.min) / (b.max - b.min) * float64(size)
+}
+
+func seriesBounds(series []Series) (x bounds, y bounds, ok bool) {
+	x = bounds{min: math.Inf(1), max: math.Inf(-1)}
+	y = bounds{min: math.Inf(1), max: math.Inf(-1)}
+	for _, s := range series {
+		for _, p := range s.Points {
+			x.min, x.max = math.Min(x.min, p.X), math.Max(x.max, p.X)
+			y.min, y.max = math.Min(y.min, p.Y), math.Max(y.max, p.Y)
+			ok = true
+		}
+	}
+	return x, y, ok
+}
+
+/*
+Draws a series inside the plot area starting at (left, top).
+Higher Y values are drawn higher up in the image.
+*/
+func (c *canvas) plot(s Series, x, y bounds, left, top, width, height, thickness int) {
+	toPixel := func(p Point) (int, int) {
+		px := left + int(math.Round(x.scale(p.X, width)))
+		py := top + height - int(math.Round(y.scale(p.Y, height)))
+		return px, py
+	}
+
+	for i, p := range s.Points {
+		px, py := toPixel(p)
+		if s.Dots || len(s.Points) == 1 {
+			c.dot(px, py, thickness+2, s.Color)
+			continue
+		}
+		if i == 0 {
+			continue
+		}
+
+		prevX, prevY := toPixel(s.Points[i-1])
+		if s.Step {
+			c.line(prevX, prevY, px, prevY, thickness, s.Color)
+			c.line(px, prevY, px, py, thickness, s.Color)
+		} else {
+			c.line(prevX, prevY, px, py, thickness, s.Color)
+		}
+	}
+}
+
+/*
+Renders a compact chart without axes or labels as a PNG.
+The series share the X axis, but each one is scaled to its own Y bounds so lines with very different values stay readable.
+*/
+func RenderSparkline(w io.Writer, width, height int, series ...Series) error {
+	const padding = 6
+
+	c := newCanvas(width, height)
+	x, _, _ := seriesBounds(series)
+	for _, s := range series {
+		_, y, ok := seriesBounds([]Series{s})
+		if !ok {
+			continue
+		}
+		c.plot(s, x, y, padding, padding, width-2*padding, height-2*padding, 2)
+	}
+
+	return png.Encode(w, c.img)
+}
+
+/*
+A line chart with a labelled grid.
+FormatX and FormatY turn axis values into labels, only digits and ": - / . % h d" can be drawn.
+*/
+type Chart struct {
+	Width   int
+	Height  int
+	Series  []Series
+	FormatX func(float64) string
+	FormatY func(float64) string
+	ZeroY   bool // Starts the Y axis at zero instead of the lowest value
+}
+
+/*
+Renders the chart as a PNG.
+*/
+func (ch *Chart) Render(w io.Writer) error {
+	const (
+		yTicks    = 5
+		xTicks    = 4
+		padding   = 12
+		thickness = 2
+	)
+
+	c := newCanvas(ch.Width, ch.Height)
+	x, y, ok := seriesBounds(ch.Series)
+	if !ok {
+		return png.Encode(w, c.img)
+	}
+	if ch.ZeroY {
+		y.min = math.Min(y.min, 0)
+	}
+	if y.min == y.max {
+		y.min, y.max = y.min-1, y.max+1
+	}
+
+	formatX, formatY := ch.FormatX, ch.FormatY
+	if formatX == nil {
+		formatX = formatNumber
+	}
+	if formatY == nil {
+		formatY = formatNumber
+	}
+
+	yLabels := make([]string, yTicks+1)
+	labelWidth := 0
+	for i := range yLabels {
+		yLabels[i] = formatY(y.min + (y.max-y.min)*float64(i)/yTicks)
+		labelWidth = max(labelWidth, textWidth(yLabels[i]))
+	}
+
+	left := padding + labelWidth + padding/2
+	top := padding
+	width := ch.Width - left - padding
+	height := ch.Height - top - 2*padding - charHeight
+	if width <= 0 || height <= 0 {
+		return png.Encode(w, c.img)
+	}
+
+	for i, label := range yLabels {
+		py := top + height - int(math.Round(float64(height)*float64(i)/yTicks))
+		c.line(left, py, left+width, py, 1, GridColor)
+		c.text(left-padding/2-textWidth(label), py-charHeight/2, label, TextColor)
+	}
+
+	for i := 0; i <= xTicks; i++ {
+		value := x.min + (x.max-x.min)*float64(i)/xTicks
+		if x.min == x.max && i > 0 {
+			break
+		}
+		px := left + int(math.Round(x.scale(value, width)))
+		c.line(px, top, px, top+height, 1, GridColor)
+
+		label := formatX(value)
+		labelX := min(max(px-textWidth(label)/2, 0), ch.Width-textWidth(label))
+		c.text(labelX, top+height+padding, label, TextColor)
+	}
+
+	for _, s := range ch.Series {
+		c.plot(s, x, y, left, top, width, height, thickness)
+	}
+
+	return png.Encode(w, c.img)
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value), 'f', 0, 64)
+}
@@ -0,0 +1,60 @@
+package chart
+
+import "image/color"
+
+// 3x5 pixel glyphs, enough for the numbers, times and dates used as axis labels.
+// Each row is a 3 bit mask, the most significant bit being the leftmost pixel.
+var glyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b010, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	':': {0b000, 0b010, 0b000, 0b010, 0b000},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	'/': {0b001, 0b001, 0b010, 0b100, 0b100},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'%': {0b101, 0b001, 0b010, 0b100, 0b101},
+	'h': {0b100, 0b100, 0b111, 0b101, 0b101},
+	'd': {0b001, 0b001, 0b111, 0b101, 0b111},
+}
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+	fontScale   = 2
+	charWidth   = (glyphWidth + 1) * fontScale
+	charHeight  = glyphHeight * fontScale
+)
+
+func textWidth(text string) int {
+	return len([]rune(text)) * charWidth
+}
+
+/*
+Draws text with its top left corner at (x, y).
+Characters without a glyph are rendered as blank space.
+*/
+func (c *canvas) text(x, y int, text string, col color.RGBA) {
+	for _, r := range text {
+		glyph, ok := glyphs[r]
+		if ok {
+			for row, mask := range glyph {
+				for column := 0; column < glyphWidth; column++ {
+					if mask&(1<<(glyphWidth-1-column)) == 0 {
+						continue
+					}
+					px := x + column*fontScale
+					py := y + row*fontScale
+					c.fillRect(px, py, px+fontScale, py+fontScale, col)
+				}
+			}
+		}
+		x += charWidth
+	}
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/schedule"
+)
+
+// The layout of the time of one-off announcements.
+const announceTimeLayout = "2006-01-02 15:04"
+
+/*
+Stores an announcement posted on the cron schedule, or once at the given time.
+at is either a time like "2025-06-01 18:00" in the announcement timezone or a delay like "2h".
+Posts are deleted deleteAfter later, when it is set.
+*/
+func CreateAnnouncement(db *sql.DB, announcement *helpers.Announcement, at, deleteAfter string, location *time.Location) *discordgo.MessageEmbed {
+	now := time.Now()
+	var nextRun time.Time
+	switch {
+	case announcement.Schedule != "" && at != "":
+		return announceError("Set either a schedule or a time, not both.")
+	case announcement.Schedule != "":
+		cron, err := schedule.Parse(announcement.Schedule)
+		if err != nil {
+			return announceError(fmt.Sprintf("Invalid schedule: %v", err))
+		}
+		nextRun = cron.Next(now.In(location))
+	case at != "":
+		if delay, err := time.ParseDuration(at); err == nil {
+			nextRun = now.Add(delay)
+		} else if nextRun, err = time.ParseInLocation(announceTimeLayout, at, location); err != nil {
+			return announceError(fmt.Sprintf("Invalid time %q, use a time like %s or a delay like 2h.", at, now.In(location).Format(announceTimeLayout)))
+		}
+		if nextRun.Before(now) {
+			return announceError("That time has already passed.")
+		}
+	default:
+		return announceError("Set a cron schedule for a recurring announcement or a time for a one-off one.")
+	}
+	if deleteAfter != "" {
+		ttl, err := time.ParseDuration(deleteAfter)
+		if err != nil || ttl <= 0 {
+			return announceError(fmt.Sprintf("Invalid delete_after %q, use a delay like 30m or 24h.", deleteAfter))
+		}
+		announcement.TTL = ttl
+	}
+
+	announcement.NextRun = nextRun
+	announcement.CreatedAt = now
+	id, err := helpers.CreateAnnouncement(db, announcement)
+	if err != nil {
+		return announceError("Announcement creation failed")
+	}
+	announcement.ID = id
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Announcement #%d created: %s", id, describeAnnouncement(*announcement)),
+		Color:       0x00ff00,
+	}
+}
+
+func DeleteAnnouncement(db *sql.DB, id int64) *discordgo.MessageEmbed {
+	found, err := helpers.DeleteAnnouncement(db, id)
+	if err != nil {
+		return announceError("Announcement removal failed")
+	}
+	if !found {
+		return announceError(fmt.Sprintf("No announcement #%d.", id))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Announcement #%d deleted", id),
+		Color:       0x00ff00,
+	}
+}
+
+/*
+Lists every scheduled announcement, the next to be posted first.
+*/
+func AnnouncementList(db *sql.DB) *discordgo.MessageEmbed {
+	announcements, err := helpers.AnnouncementsReader(db)
+	if err != nil {
+		return announceError("Failed to retrieve the announcements")
+	}
+	if len(announcements) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: "No announcements, create one with /zannounce create",
+			Color:       0xffa600,
+		}
+	}
+
+	var description strings.Builder
+	for n, announcement := range announcements {
+		line := fmt.Sprintf("**#%d** %s\n", announcement.ID, describeAnnouncement(announcement))
+		// Embed descriptions are limited to 4096 characters.
+		if description.Len()+len(line) > 3900 {
+			fmt.Fprintf(&description, "...and %d more", len(announcements)-n)
+			break
+		}
+		description.WriteString(line)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Announcements (%d)", len(announcements)),
+		Description: description.String(),
+		Color:       0xffa600,
+	}
+}
+
+func describeAnnouncement(announcement helpers.Announcement) string {
+	when := fmt.Sprintf("once <t:%d:f>", announcement.NextRun.Unix())
+	if announcement.Schedule != "" {
+		when = fmt.Sprintf("`%s`, next <t:%d:R>", announcement.Schedule, announcement.NextRun.Unix())
+	}
+	if announcement.TTL > 0 {
+		when += ", deleted after " + helpers.FormatDuration(announcement.TTL)
+	}
+	preview := strings.ReplaceAll(truncate(announcement.Content, 80), "\n", " ")
+	return fmt.Sprintf("in <#%s> %s: %s", announcement.ChannelID, when, preview)
+}
+
+func announceError(description string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "FAILED",
+		Description: description,
+		Color:       0xff0000,
+	}
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+/*
+Returns the DM telling a user they are about to be banned and how to appeal.
+*/
+func BanNotice(guildName string, modCase *helpers.ModCase, canAppeal bool) *discordgo.MessageEmbed {
+	description := fmt.Sprintf("You are being banned from **%s** by the automatic moderation for breaking the rule **%s**.", guildName, modCase.Rule)
+	if canAppeal {
+		description += "\n\nIf this was a mistake, use the button below to appeal. Moderators will review your appeal."
+	} else {
+		description += "\n\nIf this was a mistake, contact a moderator with the case number."
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Case #%d: Ban", modCase.ID),
+		Description: description,
+		Color:       0xff0000,
+	}
+}
+
+/*
+Returns the DM following a ban notice when the ban couldn't be applied.
+*/
+func BanRetracted(guildName string, modCase *helpers.ModCase) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Case #%d: Ban not applied", modCase.ID),
+		Description: fmt.Sprintf("The ban from **%s** announced above couldn't be applied, you are still a member. There is nothing to appeal.", guildName),
+		Color:       0xffa600,
+	}
+}
+
+/*
+Returns an appeal as shown to the moderators reviewing it.
+outcome is empty while the appeal waits for a review.
+*/
+func AppealReview(modCase *helpers.ModCase, appeal, outcome string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Appeal of case #%d", modCase.ID),
+		Description: truncate(appeal, 3000),
+		Color:       0xffa600,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "User", Value: fmt.Sprintf("<@%s> (%s)\n%s", modCase.UserID, modCase.Username, modCase.UserID), Inline: true},
+			{Name: "Rule", Value: modCase.Rule, Inline: true},
+			{Name: "Banned", Value: fmt.Sprintf("<t:%d:f>", modCase.CreatedAt.Unix()), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /zcase for the message that got them banned",
+		},
+	}
+	if outcome != "" {
+		embed.Color = 0x00ff00
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Outcome", Value: outcome})
+	}
+	return embed
+}
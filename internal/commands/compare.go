@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+/*
+Compares two players map by map, listing only mapName when it is set.
+The win/loss tally counts every map both players have a run on.
+*/
+func ComparePlayers(db *sql.DB, playerA, playerB, mapName string, allowedMaps []config.MapInfo) *discordgo.MessageEmbed {
+	if playerA == playerB {
+		return &discordgo.MessageEmbed{
+			Description: "Pick two different players to compare.",
+			Color:       0xffa600,
+		}
+	}
+	if mapName != "" && !helpers.IsValidTable(mapName, allowedMaps) {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Invalid map.",
+			Color:       0xff0000,
+		}
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	winsA, winsB, ties, uncontested := 0, 0, 0, 0
+	for _, mapInfo := range allowedMaps {
+		stats := helpers.PlayerStatsReader(db, mapInfo.MapName, []string{playerA, playerB}, allowedMaps)
+		if stats == nil {
+			continue
+		}
+		statsA, okA := stats[playerA]
+		statsB, okB := stats[playerB]
+
+		if okA && okB {
+			switch {
+			case statsA.BestTime < statsB.BestTime:
+				winsA++
+			case statsB.BestTime < statsA.BestTime:
+				winsB++
+			default:
+				ties++
+			}
+		} else if okA || okB {
+			uncontested++
+		}
+
+		if mapName != "" && mapInfo.MapName != mapName {
+			continue
+		}
+		if !okA && !okB && mapName == "" {
+			continue
+		}
+
+		value := compareLine(playerA, statsA) + "\n" + compareLine(playerB, statsB)
+		if okA && okB {
+			value += "\n" + compareDelta(playerA, playerB, statsA.BestTime, statsB.BestTime)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: mapInfo.MapName, Value: value})
+	}
+
+	if len(fields) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: "No records found for these players.",
+			Color:       0xffa600,
+		}
+	}
+
+	tally := fmt.Sprintf("**%s %d - %d %s**", playerA, winsA, winsB, playerB)
+	if ties > 0 {
+		tally += fmt.Sprintf(" (%d tied)", ties)
+	}
+	if uncontested > 0 {
+		tally += fmt.Sprintf("\n%d map(s) only played by one of them are not counted.", uncontested)
+	}
+	if len(fields) > maxEmbedFields {
+		tally += fmt.Sprintf("\n...and %d more maps, use the map option to see them.", len(fields)-maxEmbedFields)
+		fields = fields[:maxEmbedFields]
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s vs %s", playerA, playerB),
+		Description: tally,
+		Color:       0x00ff00,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Win/loss tally across all maps",
+		},
+	}
+}
+
+func compareLine(playerName string, stats *helpers.PlayerStats) string {
+	if stats == nil {
+		return fmt.Sprintf("%s: no runs", playerName)
+	}
+	return fmt.Sprintf("%s: %s (#%d, %d runs)", playerName, helpers.ConvertSecondsToTimer(stats.BestTime), stats.Rank, stats.TotalRuns)
+}
+
+func compareDelta(playerA, playerB string, bestA, bestB int) string {
+	switch {
+	case bestA < bestB:
+		return fmt.Sprintf("%s ahead by %s", playerA, helpers.ConvertSecondsToTimer(bestB-bestA))
+	case bestB < bestA:
+		return fmt.Sprintf("%s ahead by %s", playerB, helpers.ConvertSecondsToTimer(bestA-bestB))
+	}
+	return "Tied"
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/moderation"
+)
+
+func AddFilter(db *sql.DB, rule moderation.Rule, createdBy string) *discordgo.MessageEmbed {
+	if err := moderation.ValidateRule(rule); err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("Invalid rule: %v", err),
+			Color:       0xff0000,
+		}
+	}
+
+	err := helpers.AddModRule(db, rule, createdBy)
+	if errors.Is(err, helpers.ErrRuleExists) {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("A rule named %s already exists, remove it first to change it.", rule.Name),
+			Color:       0xff0000,
+		}
+	}
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Rule creation failed",
+			Color:       0xff0000,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Rule added: %s", describeRule(rule)),
+		Color:       0x00ff00,
+	}
+}
+
+func RemoveFilter(db *sql.DB, name string) *discordgo.MessageEmbed {
+	found, err := helpers.RemoveModRule(db, name)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Rule removal failed",
+			Color:       0xff0000,
+		}
+	}
+	if !found {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("No rule named %s.", name),
+			Color:       0xff0000,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Rule %s removed", name),
+		Color:       0x00ff00,
+	}
+}
+
+/*
+Lists every moderation rule.
+*/
+func FilterList(db *sql.DB) *discordgo.MessageEmbed {
+	rules, err := helpers.ModRulesReader(db)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Failed to retrieve the rules",
+			Color:       0xff0000,
+		}
+	}
+	if len(rules) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: "No moderation rules, add one with /zfilter add",
+			Color:       0xffa600,
+		}
+	}
+
+	var description strings.Builder
+	for n, rule := range rules {
+		line := describeRule(rule.Rule) + "\n"
+		// Embed descriptions are limited to 4096 characters.
+		if description.Len()+len(line) > 3900 {
+			fmt.Fprintf(&description, "...and %d more", len(rules)-n)
+			break
+		}
+		description.WriteString(line)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Moderation Rules (%d)", len(rules)),
+		Description: description.String(),
+		Color:       0xffa600,
+	}
+}
+
+/*
+Returns which rules a message would break and what would be done to its author.
+*/
+func FilterTest(message string, matches []moderation.Match, dryRun bool) *discordgo.MessageEmbed {
+	if len(matches) == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       "No rule broken",
+			Description: "```\n" + strings.ReplaceAll(truncate(message, 1000), "```", "'''") + "\n```",
+			Color:       0x00ff00,
+		}
+	}
+
+	strongest := matches[0]
+	var lines []string
+	for _, match := range matches {
+		if match.Action > strongest.Action {
+			strongest = match
+		}
+		lines = append(lines, fmt.Sprintf("**%s**: %s", match.Rule, matchAction(match)))
+	}
+
+	outcome := fmt.Sprintf("On a first offense: %s for breaking **%s**.", matchAction(strongest), strongest.Rule)
+	if dryRun {
+		outcome = "Dry run is on, this would only be reported. " + outcome
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%d rules broken", len(matches)),
+		Description: "```\n" + strings.ReplaceAll(truncate(message, 1000), "```", "'''") + "\n```",
+		Color:       0xff0000,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Rules", Value: truncate(strings.Join(lines, "\n"), 1000)},
+			{Name: "Outcome", Value: outcome},
+		},
+	}
+}
+
+func describeRule(rule moderation.Rule) string {
+	action := rule.Action
+	if rule.Action == moderation.ActionTimeout.String() {
+		timeout := rule.Timeout
+		if timeout == "" {
+			timeout = moderation.DefaultTimeout.String()
+		}
+		action += " " + timeout
+	}
+	match := rule.Match
+	if match == "" {
+		match = moderation.MatchContains
+	}
+	return fmt.Sprintf("**%s**: %s `%s` -> %s", rule.Name, match, strings.ReplaceAll(truncate(rule.Pattern, 200), "`", "'"), action)
+}
+
+func matchAction(match moderation.Match) string {
+	if match.Action == moderation.ActionTimeout {
+		return fmt.Sprintf("timeout (%s)", helpers.FormatDuration(match.Timeout))
+	}
+	return match.Action.String()
+}
@@ -13,7 +13,7 @@ func HelpDiscord() *discordgo.MessageEmbed {
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "/help", Value: "Shows this help message."},
 			{Name: "Slash commands", Value: "Type a '/' in the chat and select the Golden Sapling bot to see all available commands."},
-			{Name: "Link Fixing", Value: "This bot looks for X (Twitter) and Reddit links and replies with a version that provides a better media embed."},
+			{Name: "Link Fixing", Value: "This bot looks for X (Twitter), Reddit, TikTok, Instagram, Bluesky, Twitch clip and YouTube Shorts links and replies with a version that provides a better media embed. React with 🗑️ to remove the reply to your message, or use /linkfix off to stop the replies."},
 			{Name: "Automatic bans", Value: "This bot looks for common spam/scam words sent by users and automatically bans them."},
 		},
 		Footer: &discordgo.MessageEmbedFooter{Text: "Made by: L"},
@@ -3,7 +3,9 @@ package commands
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
 	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
 )
@@ -16,3 +18,52 @@ func LastRuns(db *sql.DB, playerName, mapName string, allowedMaps []config.MapIn
 
 	return helpers.TableConstructor(mapName, entry)
 }
+
+/*
+Returns the most recent runs of a player on every allowed map in a single embed.
+Used when the last runs command is called outside a map channel.
+*/
+func LastRunsSummary(db *sql.DB, playerName string, allowedMaps []config.MapInfo) *discordgo.MessageEmbed {
+	const runsPerMap = 3
+
+	var fields []*discordgo.MessageEmbedField
+	for _, mapInfo := range allowedMaps {
+		entries := helpers.LastRunsReader(db, playerName, mapInfo.MapName, allowedMaps)
+		if len(entries) == 0 {
+			continue
+		}
+		if len(entries) > runsPerMap {
+			entries = entries[:runsPerMap]
+		}
+
+		var times []string
+		for _, entry := range entries {
+			times = append(times, helpers.ConvertSecondsToTimer(entry.BestTime))
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{Name: mapInfo.MapName, Value: strings.Join(times, "\n"), Inline: true})
+	}
+
+	if len(fields) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: "No records found for this player on any map",
+			Color:       0xffa600,
+		}
+	}
+
+	description := fmt.Sprintf("%s most recent runs:", playerName)
+	if len(fields) > maxEmbedFields {
+		description = fmt.Sprintf("%s most recent runs on the first %d of %d maps:", playerName, maxEmbedFields, len(fields))
+		fields = fields[:maxEmbedFields]
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "ALL MAPS",
+		Description: description,
+		Color:       0x00ff00,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "/last_runs map:<name> to see the last 10 runs of a map",
+		},
+	}
+}
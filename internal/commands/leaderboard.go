@@ -2,11 +2,17 @@ package commands
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
 	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
 )
 
+// Discord accepts at most 25 fields per embed.
+const maxEmbedFields = 25
+
 func LeaderboardByMapName(db *sql.DB, mapName string, allowedMaps []config.MapInfo) string {
 	entries := helpers.LeaderboardReader(db, mapName, allowedMaps)
 	if entries == nil {
@@ -19,3 +25,44 @@ func LeaderboardByMapName(db *sql.DB, mapName string, allowedMaps []config.MapIn
 
 	return helpers.TableConstructor(mapName, entries)
 }
+
+/*
+Returns the podium of every allowed map in a single embed.
+Used when the leaderboard command is called outside a map channel.
+*/
+func LeaderboardSummary(db *sql.DB, allowedMaps []config.MapInfo) *discordgo.MessageEmbed {
+	description := "Top 3 of every map:"
+	if len(allowedMaps) > maxEmbedFields {
+		description = fmt.Sprintf("Top 3 of the first %d of %d maps:", maxEmbedFields, len(allowedMaps))
+		allowedMaps = allowedMaps[:maxEmbedFields]
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	for _, mapInfo := range allowedMaps {
+		entries := helpers.LeaderboardReader(db, mapInfo.MapName, allowedMaps)
+
+		value := "No records yet."
+		if len(entries) > 0 {
+			var lines []string
+			for _, entry := range entries {
+				if entry.Rank > 3 {
+					break
+				}
+				lines = append(lines, fmt.Sprintf("#%d %s - %s", entry.Rank, entry.PlayerName, helpers.ConvertSecondsToTimer(entry.BestTime)))
+			}
+			value = strings.Join(lines, "\n")
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{Name: mapInfo.MapName, Value: value, Inline: true})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "LEADERBOARDS",
+		Description: description,
+		Color:       0xffa600,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "/leaderboard map:<name> to see a full leaderboard",
+		},
+	}
+}
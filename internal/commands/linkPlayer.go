@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+func LinkPlayer(db *sql.DB, discordID, playerName string) *discordgo.MessageEmbed {
+	if len(playerName) <= 0 {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Invalid player name.",
+			Color:       0xff0000,
+		}
+	}
+
+	err := helpers.LinkPlayer(db, discordID, playerName)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Player link failed",
+			Color:       0xff0000,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("<@%s> -> %s", discordID, playerName),
+		Color:       0x00ff00,
+	}
+}
+
+func UnlinkPlayer(db *sql.DB, discordID string) *discordgo.MessageEmbed {
+	found, err := helpers.UnlinkPlayer(db, discordID)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Player unlink failed",
+			Color:       0xff0000,
+		}
+	}
+	if !found {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("<@%s> is not linked to any player.", discordID),
+			Color:       0xff0000,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("<@%s> unlinked", discordID),
+		Color:       0x00ff00,
+	}
+}
@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/linkfix"
+)
+
+func AddLinkProvider(db *sql.DB, provider linkfix.Provider) *discordgo.MessageEmbed {
+	if err := linkfix.ValidateProvider(provider); err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("Invalid provider: %v", err),
+			Color:       0xff0000,
+		}
+	}
+
+	err := helpers.AddLinkProvider(db, provider)
+	if errors.Is(err, helpers.ErrProviderExists) {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("A provider named %s already exists, remove it first to change it.", provider.Name),
+			Color:       0xff0000,
+		}
+	}
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Provider creation failed",
+			Color:       0xff0000,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Provider added: %s", describeProvider(provider)),
+		Color:       0x00ff00,
+	}
+}
+
+func RemoveLinkProvider(db *sql.DB, name string) *discordgo.MessageEmbed {
+	found, err := helpers.RemoveLinkProvider(db, name)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Provider removal failed",
+			Color:       0xff0000,
+		}
+	}
+	if !found {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("No provider named %s.", name),
+			Color:       0xff0000,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Provider %s removed", name),
+		Color:       0x00ff00,
+	}
+}
+
+func SetLinkProviderEnabled(db *sql.DB, name string, enabled bool) *discordgo.MessageEmbed {
+	found, err := helpers.SetLinkProviderEnabled(db, name, enabled)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Provider update failed",
+			Color:       0xff0000,
+		}
+	}
+	if !found {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("No provider named %s.", name),
+			Color:       0xff0000,
+		}
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("Provider %s %s", name, state),
+		Color:       0x00ff00,
+	}
+}
+
+/*
+Lists every link provider, enabled or not, and the allowed and denied channels.
+*/
+func LinkProviderList(db *sql.DB) *discordgo.MessageEmbed {
+	providers, err := helpers.LinkProvidersReader(db)
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Failed to retrieve the providers",
+			Color:       0xff0000,
+		}
+	}
+	if len(providers) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: "No link providers, add one with /zlinkfix add",
+			Color:       0xffa600,
+		}
+	}
+
+	var description strings.Builder
+	for n, provider := range providers {
+		line := describeProvider(provider) + "\n"
+		// Embed descriptions are limited to 4096 characters.
+		if description.Len()+len(line) > 3900 {
+			fmt.Fprintf(&description, "...and %d more", len(providers)-n)
+			break
+		}
+		description.WriteString(line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Link Providers (%d)", len(providers)),
+		Description: description.String(),
+		Color:       0xffa600,
+	}
+
+	channels, _ := helpers.LinkFixChannelsReader(db)
+	var allowed, denied []string
+	for channelID, mode := range channels {
+		if mode == helpers.LinkFixChannelAllow {
+			allowed = append(allowed, "<#"+channelID+">")
+		} else {
+			denied = append(denied, "<#"+channelID+">")
+		}
+	}
+	sort.Strings(allowed)
+	sort.Strings(denied)
+	if len(allowed) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Only in", Value: truncate(strings.Join(allowed, " "), 1000)})
+	}
+	if len(denied) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Never in", Value: truncate(strings.Join(denied, " "), 1000)})
+	}
+	return embed
+}
+
+/*
+Returns the links the bot would reply with for a message.
+*/
+func LinkFixTest(message string, fixedLinks []string) *discordgo.MessageEmbed {
+	quoted := "```\n" + strings.ReplaceAll(truncate(message, 1000), "```", "'''") + "\n```"
+	if len(fixedLinks) == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       "No link to fix",
+			Description: quoted,
+			Color:       0xffa600,
+		}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%d links fixed", len(fixedLinks)),
+		Description: quoted,
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Reply", Value: truncate(strings.Join(fixedLinks, "\n"), 1000)},
+		},
+	}
+}
+
+func describeProvider(provider linkfix.Provider) string {
+	state := ""
+	if !provider.Enabled {
+		state = " (disabled)"
+	}
+	return fmt.Sprintf("**%s**%s: `%s` group %d -> `%s`", provider.Name, state, strings.ReplaceAll(truncate(provider.Pattern, 200), "`", "'"), provider.CaptureGroup, provider.Replacement)
+}
+
+/*
+Turns link fixing off or back on for the messages of a user.
+*/
+func SetLinkFixOptOut(db *sql.DB, userID string, optOut bool) *discordgo.MessageEmbed {
+	if err := helpers.SetLinkFixOptOut(db, userID, optOut); err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Failed to save your choice, please try again later.",
+			Color:       0xff0000,
+		}
+	}
+
+	description := "The bot won't reply with fixed links to your messages anymore. Use `/linkfix on` to get them back."
+	if !optOut {
+		description = "The bot will reply with fixed links to your messages again."
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: description,
+		Color:       0x00ff00,
+	}
+}
+
+/*
+Puts a channel on the allow or deny list of link fixing, an empty mode takes it off both.
+*/
+func SetLinkFixChannel(db *sql.DB, channelID, mode, setBy string) *discordgo.MessageEmbed {
+	if err := helpers.SetLinkFixChannel(db, channelID, mode, setBy); err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Channel update failed",
+			Color:       0xff0000,
+		}
+	}
+
+	var description string
+	switch mode {
+	case helpers.LinkFixChannelAllow:
+		description = fmt.Sprintf("Links are fixed in <#%s>. Channels not allowed get no replies while any channel is allowed.", channelID)
+	case helpers.LinkFixChannelDeny:
+		description = fmt.Sprintf("Links are no longer fixed in <#%s>.", channelID)
+	default:
+		description = fmt.Sprintf("<#%s> removed from the allowed and denied channels.", channelID)
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: description,
+		Color:       0x00ff00,
+	}
+}
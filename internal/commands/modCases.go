@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+// How many cases /zcases lists.
+const userCasesLimit = 15
+
+/*
+Returns the full record of a moderation case and its history, as posted to the mod log channel.
+*/
+func ModCaseEmbed(modCase *helpers.ModCase, events []helpers.CaseEvent) *discordgo.MessageEmbed {
+	moderator := "Automatic"
+	if modCase.ModeratorID != "" {
+		moderator = fmt.Sprintf("<@%s>", modCase.ModeratorID)
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "User", Value: fmt.Sprintf("<@%s> (%s)\n%s", modCase.UserID, modCase.Username, modCase.UserID), Inline: true},
+		{Name: "Moderator", Value: moderator, Inline: true},
+	}
+	if modCase.ChannelID != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Channel", Value: fmt.Sprintf("<#%s>", modCase.ChannelID), Inline: true})
+	}
+	if modCase.Rule != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Rule", Value: modCase.Rule, Inline: true})
+	}
+	if modCase.Reason != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Reason", Value: truncate(modCase.Reason, 1000)})
+	}
+	if modCase.Content != "" {
+		content := strings.ReplaceAll(truncate(modCase.Content, 990), "```", "'''")
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Message", Value: "```\n" + content + "\n```"})
+	}
+	if len(modCase.Attachments) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Attachments", Value: truncate(strings.Join(modCase.Attachments, "\n"), 1000)})
+	}
+
+	color := 0xff0000
+	if modCase.Error != "" {
+		color = 0xffa600
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Failed", Value: truncate(modCase.Error, 1000)})
+	}
+	if len(events) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "History", Value: caseHistory(events)})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("Case #%d: %s", modCase.ID, caseAction(modCase)),
+		Color:     color,
+		Fields:    fields,
+		Timestamp: modCase.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+/*
+Returns a moderation case by its number.
+*/
+func ModCase(db *sql.DB, id int64) *discordgo.MessageEmbed {
+	modCase := helpers.ModCaseReader(db, id)
+	if modCase == nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("Case #%d not found", id),
+			Color:       0xff0000,
+		}
+	}
+	return ModCaseEmbed(modCase, helpers.CaseEventsReader(db, id))
+}
+
+/*
+Lists the latest moderation cases of a user.
+*/
+func UserModCases(db *sql.DB, user *discordgo.User) *discordgo.MessageEmbed {
+	cases, total := helpers.UserModCasesReader(db, user.ID, userCasesLimit)
+	if total == 0 {
+		return &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("No cases for <@%s>", user.ID),
+			Color:       0x00ff00,
+		}
+	}
+
+	var lines []string
+	for _, modCase := range cases {
+		line := fmt.Sprintf("**#%d** <t:%d:d> %s", modCase.ID, modCase.CreatedAt.Unix(), caseAction(&modCase))
+		switch {
+		case modCase.Rule != "":
+			line += ": " + modCase.Rule
+		case modCase.Reason != "":
+			line += ": " + truncate(modCase.Reason, 80)
+		}
+		if modCase.Error != "" {
+			line += " (failed)"
+		}
+		lines = append(lines, line)
+	}
+
+	description := strings.Join(lines, "\n")
+	if total > len(cases) {
+		description += fmt.Sprintf("\n...and %d older cases", total-len(cases))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Cases of %s", user.Username),
+		Description: description,
+		Color:       0xffa600,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /zcase with a case number for the details",
+		},
+	}
+}
+
+func caseHistory(events []helpers.CaseEvent) string {
+	var lines []string
+	for _, event := range events {
+		line := fmt.Sprintf("<t:%d:f> %s", event.CreatedAt.Unix(), strings.ToUpper(event.Event[:1])+event.Event[1:])
+		if event.ActorID != "" {
+			line += fmt.Sprintf(" by <@%s>", event.ActorID)
+		}
+		if event.Note != "" {
+			line += ": " + truncate(event.Note, 150)
+		}
+		lines = append(lines, line)
+	}
+	// Fields are limited to 1024 characters, the latest events matter most.
+	history := strings.Join(lines, "\n")
+	for len(history) > 1024 && len(lines) > 1 {
+		lines = lines[1:]
+		history = "...\n" + strings.Join(lines, "\n")
+	}
+	return truncate(history, 1000)
+}
+
+func caseAction(modCase *helpers.ModCase) string {
+	action := strings.ToUpper(modCase.Action[:1]) + modCase.Action[1:]
+	if modCase.Duration > 0 {
+		action += fmt.Sprintf(" (%s)", helpers.FormatDuration(modCase.Duration))
+	}
+	return action
+}
+
+/*
+Cuts the text to at most limit bytes followed by "...", without splitting a character.
+*/
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit] + "..."
+}
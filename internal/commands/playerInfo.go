@@ -23,6 +23,7 @@ func PlayerInfo(db *sql.DB, playerName, mapName string, allowedMaps []config.Map
 		Description: fmt.Sprintf("%s statistics:", playerName),
 		Color:       0x00ff00,
 		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Rank", Value: fmt.Sprintf("#%d", entry.Rank), Inline: true},
 			{Name: "Best Time", Value: fmt.Sprintf("%s (x%s)", entry.BestTime, entry.BestTimeAmount), Inline: true},
 			{Name: "Total Runs", Value: fmt.Sprint(entry.TotalRuns), Inline: true},
 			{Name: "Worst Time", Value: entry.SlowestRun, Inline: true},
@@ -35,3 +36,46 @@ func PlayerInfo(db *sql.DB, playerName, mapName string, allowedMaps []config.Map
 		},
 	}
 }
+
+/*
+Returns the statistics of a player on every allowed map in a single embed.
+Used when the player info command is called outside a map channel.
+*/
+func PlayerInfoSummary(db *sql.DB, playerName string, allowedMaps []config.MapInfo) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, mapInfo := range allowedMaps {
+		entry := helpers.PlayerInfoReader(db, playerName, mapInfo.MapName, allowedMaps)
+		if entry == nil || entry.TotalRuns == 0 {
+			continue
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   mapInfo.MapName,
+			Value:  fmt.Sprintf("Rank: #%d\nBest: %s (x%s)\nRuns: %d\nTotal: %s", entry.Rank, entry.BestTime, entry.BestTimeAmount, entry.TotalRuns, entry.TotalTime),
+			Inline: true,
+		})
+	}
+
+	if len(fields) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: "No records found for this player on any map",
+			Color:       0xffa600,
+		}
+	}
+
+	description := fmt.Sprintf("%s statistics:", playerName)
+	if len(fields) > maxEmbedFields {
+		description = fmt.Sprintf("%s statistics on the first %d of %d maps:", playerName, maxEmbedFields, len(fields))
+		fields = fields[:maxEmbedFields]
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "ALL MAPS",
+		Description: description,
+		Color:       0x00ff00,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "/player_info map:<name> to see a single map",
+		},
+	}
+}
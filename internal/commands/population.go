@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/chart"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+type populationPeriod struct {
+	span       time.Duration
+	bucket     time.Duration // Samples are averaged over buckets of this size to keep the chart readable
+	dateFormat string
+}
+
+var populationPeriods = map[string]populationPeriod{
+	"day":   {span: 24 * time.Hour, bucket: 10 * time.Minute, dateFormat: "15:04"},
+	"week":  {span: 7 * 24 * time.Hour, bucket: time.Hour, dateFormat: "01/02"},
+	"month": {span: 30 * 24 * time.Hour, bucket: 6 * time.Hour, dateFormat: "01/02"},
+}
+
+// Names of the periods accepted by Population, shortest first.
+var PopulationPeriods = []string{"day", "week", "month"}
+
+/*
+Returns a chart of the players online on a watched server over the period, with the peak, the average and the busiest hours.
+The returned file is nil when there is nothing to draw.
+*/
+func Population(db *sql.DB, serverName, periodName string) (*discordgo.MessageEmbed, *discordgo.File) {
+	period, ok := populationPeriods[periodName]
+	if !ok {
+		return &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("Unknown period %s, use one of: %s", periodName, strings.Join(PopulationPeriods, ", ")),
+			Color:       0xff0000,
+		}, nil
+	}
+
+	samples := helpers.PopulationReader(db, serverName, time.Now().Add(-period.span))
+	if len(samples) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("No player counts recorded for %s in the last %s", serverName, periodName),
+			Color:       0xffa600,
+		}, nil
+	}
+
+	peak := samples[0]
+	total := 0
+	var hourTotals, hourSamples [24]int
+	for _, sample := range samples {
+		if sample.Players > peak.Players {
+			peak = sample
+		}
+		total += sample.Players
+		hour := sample.RecordedAt.UTC().Hour()
+		hourTotals[hour] += sample.Players
+		hourSamples[hour]++
+	}
+	average := float64(total) / float64(len(samples))
+
+	embed := &discordgo.MessageEmbed{
+		Title:       serverName,
+		Description: fmt.Sprintf("Players online over the last %s:", periodName),
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Peak", Value: fmt.Sprintf("%d players\n<t:%d:f>", peak.Players, peak.RecordedAt.Unix()), Inline: true},
+			{Name: "Average", Value: fmt.Sprintf("%.1f players", average), Inline: true},
+			{Name: "Busiest Hours", Value: busiestHours(hourTotals, hourSamples), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s average  %s peak  chart times in UTC", chart.PaletteEmoji(0), chart.PaletteEmoji(1)),
+		},
+	}
+
+	averageSeries := chart.Series{Name: "Average", Color: chart.PaletteColor(0)}
+	peakSeries := chart.Series{Name: "Peak", Color: chart.PaletteColor(1)}
+	bucketSeconds := int64(period.bucket.Seconds())
+	for start := 0; start < len(samples); {
+		bucket := samples[start].RecordedAt.Unix() / bucketSeconds
+		end, sum, bucketPeak := start, 0, 0
+		for ; end < len(samples) && samples[end].RecordedAt.Unix()/bucketSeconds == bucket; end++ {
+			sum += samples[end].Players
+			bucketPeak = max(bucketPeak, samples[end].Players)
+		}
+		x := float64(bucket * bucketSeconds)
+		averageSeries.Points = append(averageSeries.Points, chart.Point{X: x, Y: float64(sum) / float64(end-start)})
+		peakSeries.Points = append(peakSeries.Points, chart.Point{X: x, Y: float64(bucketPeak)})
+		start = end
+	}
+
+	populationChart := &chart.Chart{
+		Width:  800,
+		Height: 400,
+		Series: []chart.Series{peakSeries, averageSeries},
+		ZeroY:  true,
+		FormatX: func(value float64) string {
+			return time.Unix(int64(value), 0).UTC().Format(period.dateFormat)
+		},
+		FormatY: func(value float64) string {
+			return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := populationChart.Render(&buf); err != nil {
+		log.Printf("[DISCORD] Failed to render population chart for %s: %v", serverName, err)
+		return embed, nil
+	}
+
+	const fileName = "population.png"
+	embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + fileName}
+	return embed, &discordgo.File{Name: fileName, ContentType: "image/png", Reader: &buf}
+}
+
+/*
+Lists the three hours of the day with the most players on average.
+Hours are shown as Discord timestamps so everyone reads them in their own timezone.
+*/
+func busiestHours(hourTotals, hourSamples [24]int) string {
+	type hourAverage struct {
+		hour    int
+		average float64
+	}
+	var hours []hourAverage
+	for hour := range hourTotals {
+		if hourSamples[hour] == 0 || hourTotals[hour] == 0 {
+			continue
+		}
+		hours = append(hours, hourAverage{hour, float64(hourTotals[hour]) / float64(hourSamples[hour])})
+	}
+	if len(hours) == 0 {
+		return "Nobody played"
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return hours[i].average > hours[j].average
+	})
+
+	midnight := time.Now().UTC().Truncate(24 * time.Hour)
+	var lines []string
+	for _, h := range hours[:min(len(hours), 3)] {
+		lines = append(lines, fmt.Sprintf("<t:%d:t> - %.1f players", midnight.Add(time.Duration(h.hour)*time.Hour).Unix(), h.average))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/chart"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+// Fields of the profile embed that are not about a single map.
+const profileSummaryFields = 5
+
+/*
+Returns the profile of a player across every map, with a sparkline of the personal best progression over time.
+The returned file is nil when there is nothing to draw.
+*/
+func PlayerProfile(db *sql.DB, playerName string, allowedMaps []config.MapInfo) (*discordgo.MessageEmbed, *discordgo.File) {
+	profile := helpers.PlayerProfileReader(db, playerName, allowedMaps)
+	if profile == nil {
+		return &discordgo.MessageEmbed{
+			Description: "An error occurred while fetching the player profile.",
+			Color:       0xff0000,
+		}, nil
+	}
+	if len(profile.Maps) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("No records found for %s on any map", playerName),
+			Color:       0xffa600,
+		}, nil
+	}
+
+	description := fmt.Sprintf("Profile across %d map(s):", len(profile.Maps))
+	maps := profile.Maps
+	if limit := maxEmbedFields - profileSummaryFields; len(maps) > limit {
+		description = fmt.Sprintf("Profile across %d maps, showing the first %d:", len(maps), limit)
+		maps = maps[:limit]
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	var series []chart.Series
+	for n, mapProfile := range maps {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   chart.PaletteEmoji(n) + " " + mapProfile.MapName,
+			Value:  fmt.Sprintf("#%d - %s\n%d runs", mapProfile.Rank, helpers.ConvertSecondsToTimer(mapProfile.BestTime), mapProfile.TotalRuns),
+			Inline: true,
+		})
+
+		series = append(series, chart.Series{
+			Name:   mapProfile.MapName,
+			Points: progressionPoints(mapProfile.Progression, profile.FirstRunAt, profile.LastRunAt),
+			Color:  chart.PaletteColor(n),
+			Step:   true,
+		})
+	}
+
+	firstRun := "Unknown"
+	if !profile.FirstRunAt.IsZero() {
+		firstRun = fmt.Sprintf("<t:%d:D>", profile.FirstRunAt.Unix())
+	}
+	recent := fmt.Sprintf("%d runs in the last 7 days", profile.RecentRuns)
+	if !profile.LastRunAt.IsZero() {
+		recent += fmt.Sprintf("\nLast run <t:%d:R>", profile.LastRunAt.Unix())
+	}
+
+	fields = append(fields,
+		&discordgo.MessageEmbedField{Name: "Total Runs", Value: fmt.Sprint(profile.TotalRuns), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Time Played", Value: helpers.ConvertSecondsToTimer(profile.TotalTime), Inline: true},
+		&discordgo.MessageEmbedField{Name: "First Run", Value: firstRun, Inline: true},
+		&discordgo.MessageEmbedField{Name: "Medals", Value: fmt.Sprintf("WR: %d\nTop 3: %d\nTop 10: %d", profile.Top1, profile.Top3, profile.Top10), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Recent Activity", Value: recent, Inline: true},
+	)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       playerName,
+		Description: description,
+		Color:       0x00ff00,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Lines show the personal best progression of each map",
+		},
+	}
+
+	if profile.LastRunAt.IsZero() {
+		return embed, nil // No run has a date to place on the chart.
+	}
+	var buf bytes.Buffer
+	if err := chart.RenderSparkline(&buf, 400, 80, series...); err != nil {
+		log.Printf("[DISCORD] Failed to render profile sparkline for %s: %v", playerName, err)
+		return embed, nil
+	}
+
+	const fileName = "progression.png"
+	embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + fileName}
+	return embed, &discordgo.File{Name: fileName, ContentType: "image/png", Reader: &buf}
+}
+
+/*
+Places the personal bests of a map on a time axis ending at the last run of the player.
+Runs recorded before dates were stored are older than every dated run, the best of them starts the line at the first known date.
+*/
+func progressionPoints(progression []helpers.ProgressPoint, firstRunAt, lastRunAt time.Time) []chart.Point {
+	var points []chart.Point
+	for n, point := range progression {
+		if point.RecordedAt.IsZero() {
+			if n == len(progression)-1 || !progression[n+1].RecordedAt.IsZero() {
+				points = append(points, chart.Point{X: float64(firstRunAt.Unix()), Y: float64(point.BestTime)})
+			}
+			continue
+		}
+		points = append(points, chart.Point{X: float64(point.RecordedAt.Unix()), Y: float64(point.BestTime)})
+	}
+	if len(points) == 0 || firstRunAt.IsZero() {
+		return nil
+	}
+	return append(points, chart.Point{X: float64(lastRunAt.Unix()), Y: points[len(points)-1].Y})
+}
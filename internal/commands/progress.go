@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/chart"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+/*
+Returns a chart of every run of a player on a map, with their personal best and the map record over time.
+The returned file is nil when there is nothing to draw.
+*/
+func PlayerProgress(db *sql.DB, playerName, mapName string, allowedMaps []config.MapInfo) (*discordgo.MessageEmbed, *discordgo.File) {
+	runs := helpers.RunHistoryReader(db, playerName, mapName, allowedMaps)
+	if len(runs) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("No records found for this player on %s", mapName),
+			Color:       0xffa600,
+		}, nil
+	}
+	records := helpers.WRProgressionReader(db, mapName, allowedMaps)
+
+	timeline, dated := runTimeline(slices.Concat(runs, records))
+	firstID, lastID := runs[0].ID, runs[len(runs)-1].ID
+	runSeries := chart.Series{Name: "Runs", Color: chart.PaletteColor(1), Dots: true}
+	bestSeries := chart.Series{Name: "Personal best", Color: chart.PaletteColor(0), Step: true}
+	for _, run := range runs {
+		runSeries.Points = append(runSeries.Points, chart.Point{X: timeline[run.ID], Y: float64(run.TimeScore)})
+		if len(bestSeries.Points) == 0 || float64(run.TimeScore) < bestSeries.Points[len(bestSeries.Points)-1].Y {
+			bestSeries.Points = append(bestSeries.Points, chart.Point{X: timeline[run.ID], Y: float64(run.TimeScore)})
+		}
+	}
+	bestTime := bestSeries.Points[len(bestSeries.Points)-1].Y
+	bestSeries.Points = append(bestSeries.Points, chart.Point{X: timeline[lastID], Y: bestTime})
+
+	// Only the part of the record progression that overlaps with the player's runs is drawn.
+	recordSeries := chart.Series{Name: "World record", Color: chart.PaletteColor(2), Step: true}
+	for _, record := range records {
+		point := chart.Point{X: timeline[record.ID], Y: float64(record.TimeScore)}
+		switch {
+		case record.ID <= firstID:
+			point.X = timeline[firstID]
+			recordSeries.Points = []chart.Point{point}
+		case record.ID <= lastID:
+			recordSeries.Points = append(recordSeries.Points, point)
+		}
+	}
+	if len(recordSeries.Points) > 0 {
+		recordTime := recordSeries.Points[len(recordSeries.Points)-1].Y
+		recordSeries.Points = append(recordSeries.Points, chart.Point{X: timeline[lastID], Y: recordTime})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       mapName,
+		Description: fmt.Sprintf("%s progression:", playerName),
+		Color:       0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Personal Best", Value: helpers.ConvertSecondsToTimer(int(bestTime)), Inline: true},
+			{Name: "Total Runs", Value: fmt.Sprint(len(runs)), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s runs  %s personal best  %s world record", chart.PaletteEmoji(1), chart.PaletteEmoji(0), chart.PaletteEmoji(2)),
+		},
+	}
+	if len(records) > 0 {
+		record := records[len(records)-1]
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "World Record",
+			Value:  fmt.Sprintf("%s - %s", record.PlayerName, helpers.ConvertSecondsToTimer(record.TimeScore)),
+			Inline: true,
+		})
+	}
+
+	progressChart := &chart.Chart{
+		Width:  800,
+		Height: 400,
+		Series: []chart.Series{recordSeries, bestSeries, runSeries},
+		FormatX: func(value float64) string {
+			if !dated {
+				return ""
+			}
+			return time.Unix(int64(math.Round(value)), 0).Format("2006-01-02")
+		},
+		FormatY: func(value float64) string {
+			return helpers.ConvertSecondsToTimer(int(math.Round(value)))
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := progressChart.Render(&buf); err != nil {
+		log.Printf("[DISCORD] Failed to render progress chart for %s on %s: %v", playerName, mapName, err)
+		return embed, nil
+	}
+
+	const fileName = "progress.png"
+	embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + fileName}
+	return embed, &discordgo.File{Name: fileName, ContentType: "image/png", Reader: &buf}
+}
+
+/*
+Returns the X position of every run, the unix time of its date.
+Runs without a date are spread evenly by run order between the dated runs around them.
+When no run has a date, the runs are placed by their index and the second result is false.
+*/
+func runTimeline(runs []helpers.RunRecord) (map[int]float64, bool) {
+	byID := make(map[int]helpers.RunRecord, len(runs))
+	for _, run := range runs {
+		byID[run.ID] = run
+	}
+	ids := slices.Sorted(maps.Keys(byID))
+
+	var datedIndexes []int
+	for n, id := range ids {
+		if !byID[id].RecordedAt.IsZero() {
+			datedIndexes = append(datedIndexes, n)
+		}
+	}
+
+	timeline := make(map[int]float64, len(ids))
+	if len(datedIndexes) == 0 {
+		for n, id := range ids {
+			timeline[id] = float64(n)
+		}
+		return timeline, false
+	}
+
+	at := func(n int) float64 { return float64(byID[ids[n]].RecordedAt.Unix()) }
+	// Spacing of the undated runs before the first or after the last dated run.
+	first, last := datedIndexes[0], datedIndexes[len(datedIndexes)-1]
+	step := float64(time.Hour / time.Second)
+	if last > first && at(last) > at(first) {
+		step = (at(last) - at(first)) / float64(last-first)
+	}
+
+	previous := -1
+	for n, id := range ids {
+		next, _ := slices.BinarySearch(datedIndexes, n)
+		switch {
+		case next < len(datedIndexes) && datedIndexes[next] == n:
+			previous = n
+			timeline[id] = at(n)
+		case previous < 0:
+			timeline[id] = at(first) - float64(first-n)*step
+		case next == len(datedIndexes):
+			timeline[id] = at(previous) + float64(n-previous)*step
+		default:
+			following := datedIndexes[next]
+			timeline[id] = at(previous) + (at(following)-at(previous))*float64(n-previous)/float64(following-previous)
+		}
+	}
+	return timeline, true
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+/*
+Returns the result of a console command ran through RCON.
+*/
+func RconResult(line, output string, err error) *discordgo.MessageEmbed {
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("`%s` failed: %v", line, err),
+			Color:       0xff0000,
+		}
+	}
+
+	output = strings.TrimSpace(output)
+	if output == "" {
+		output = "No console output."
+	} else {
+		// Embed descriptions are limited to 4096 characters.
+		if len(output) > 3500 {
+			output = "..." + output[len(output)-3500:]
+		}
+		output = "```\n" + strings.ReplaceAll(output, "```", "'''") + "\n```"
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: fmt.Sprintf("`%s`\n%s", line, output),
+		Color:       0x00ff00,
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/supervisor"
+)
+
+/*
+Returns the state of the game server and of its restart policy.
+*/
+func ServerStatus(status supervisor.Status) *discordgo.MessageEmbed {
+	if errors.Is(status.RunningErr, supervisor.ErrNotSupervised) {
+		return &discordgo.MessageEmbed{
+			Description: "The game server is not supervised by the bot, set SERVER_SUPERVISOR to manage it.",
+			Color:       0xffa600,
+		}
+	}
+
+	process := "Stopped"
+	switch {
+	case status.RunningErr != nil:
+		process = fmt.Sprintf("Unknown: %v", status.RunningErr)
+	case status.Running:
+		process = "Running"
+	}
+	listed := "Unknown"
+	if status.Listed != nil {
+		listed = "No"
+		if *status.Listed {
+			listed = "Yes"
+		}
+	}
+
+	probes := "None, the server list is used"
+	probesFailing := false
+	if len(status.Probes) > 0 {
+		var lines []string
+		for name, err := range status.Probes {
+			if err != nil {
+				probesFailing = true
+				lines = append(lines, fmt.Sprintf("%s: %v", name, err))
+			} else {
+				lines = append(lines, name+": OK")
+			}
+		}
+		sort.Strings(lines)
+		probes = strings.Join(lines, "\n")
+	}
+
+	policy := status.Policy
+	restarts := "On"
+	switch {
+	case status.Paused:
+		restarts = "Off, stopped by an admin"
+	case policy.RestartsLastHour >= policy.MaxRestartsPerHour && time.Now().Before(policy.NextRestart):
+		restarts = fmt.Sprintf("Paused until <t:%d:t>", policy.NextRestart.Unix())
+	case time.Now().Before(policy.NextRestart):
+		restarts = fmt.Sprintf("On, next one <t:%d:R> at the earliest", policy.NextRestart.Unix())
+	}
+	lastRestart := "None in the last hour"
+	if !policy.LastRestart.IsZero() {
+		lastRestart = fmt.Sprintf("<t:%d:R>", policy.LastRestart.Unix())
+	}
+
+	color := 0x00ff00
+	switch {
+	case !status.Running || probesFailing:
+		color = 0xff0000
+	case status.Listed != nil && !*status.Listed:
+		color = 0xffa600
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Game Server",
+		Description: fmt.Sprintf("Managed by the %s supervisor", status.Supervisor),
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Process", Value: process, Inline: true},
+			{Name: "In Server List", Value: listed, Inline: true},
+			{Name: "Probes", Value: probes, Inline: true},
+			{Name: "Failed Checks", Value: fmt.Sprintf("%d/%d", policy.Failures, policy.FailuresBeforeRestart), Inline: true},
+			{Name: "Automatic Restarts", Value: restarts, Inline: true},
+			{Name: "Restarts This Hour", Value: fmt.Sprintf("%d/%d", policy.RestartsLastHour, policy.MaxRestartsPerHour), Inline: true},
+			{Name: "Last Restart", Value: lastRestart, Inline: true},
+		},
+	}
+}
+
+/*
+Returns the result of an admin action on the game server.
+*/
+func ServerAction(action string, err error) *discordgo.MessageEmbed {
+	if err != nil {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: fmt.Sprintf("Game server %s failed: %v", action, err),
+			Color:       0xff0000,
+		}
+	}
+
+	description := "Game server restarted, automatic restarts are on."
+	if action == "stop" {
+		description = "Game server stopped, automatic restarts are off until the next /zserver restart."
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "SUCCESS",
+		Description: description,
+		Color:       0x00ff00,
+	}
+}
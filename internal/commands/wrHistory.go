@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+/*
+Returns every world record transition of a map, newest first, with how long each record stood.
+*/
+func WRHistory(db *sql.DB, mapName string, allowedMaps []config.MapInfo) *discordgo.MessageEmbed {
+	if !helpers.IsValidTable(mapName, allowedMaps) {
+		return &discordgo.MessageEmbed{
+			Title:       "FAILED",
+			Description: "Invalid map.",
+			Color:       0xff0000,
+		}
+	}
+
+	entries := helpers.WRHistoryReader(db, mapName, allowedMaps)
+	if len(entries) == 0 {
+		return &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("No records found on %s yet.", mapName),
+			Color:       0xffa600,
+		}
+	}
+
+	// Embed descriptions are limited to 4096 characters, 25 lines stay well under it.
+	const maxLines = 25
+
+	var lines []string
+	reigns := make(map[string]time.Duration)
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		date := "unknown date"
+		reign := "unknown"
+		if !entry.RecordedAt.IsZero() {
+			date = fmt.Sprintf("<t:%d:d>", entry.RecordedAt.Unix())
+
+			end := entry.ReignEnd
+			if entry.Current {
+				end = time.Now()
+			}
+			if !end.IsZero() {
+				reigns[entry.PlayerName] += end.Sub(entry.RecordedAt)
+				reign = helpers.FormatDuration(end.Sub(entry.RecordedAt))
+			}
+		}
+		if entry.Current {
+			reign += ", current"
+		}
+
+		if len(lines) < maxLines {
+			lines = append(lines, fmt.Sprintf("`%s` **%s** - %s (%s)", helpers.ConvertSecondsToTimer(entry.TimeScore), entry.PlayerName, date, reign))
+		}
+	}
+	if len(entries) > maxLines {
+		lines = append(lines, fmt.Sprintf("... and %d older records", len(entries)-maxLines))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       mapName + " WORLD RECORD HISTORY",
+		Description: strings.Join(lines, "\n"),
+		Color:       0xffa600,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d record(s)", len(entries)),
+		},
+	}
+
+	var longestHolder string
+	for playerName, reign := range reigns {
+		if longestHolder == "" || reign > reigns[longestHolder] || (reign == reigns[longestHolder] && playerName < longestHolder) {
+			longestHolder = playerName
+		}
+	}
+	if longestHolder != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Longest Total Reign",
+			Value: fmt.Sprintf("%s - %s", longestHolder, helpers.FormatDuration(reigns[longestHolder])),
+		})
+	}
+
+	return embed
+}
@@ -1,24 +1,80 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	// Windows hosts have no timezone database for ANNOUNCE_TIMEZONE.
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 )
 
 type MapInfo struct {
-	MessageID string // ID of the map message used in the leaderboard channel
-	MapName   string // Name of the map, used for validation
-	ChannelID string // ID of the channel where the map is discussed
+	MessageID string       // ID of the map message used in the leaderboard channel
+	MapName   string       // Name of the map, used for validation
+	ChannelID string       // ID of the channel where the map is discussed
+	Panel     *PanelLayout // In-game leaderboard panel, nil when the map has none
+}
+
+type Vector [3]float64
+
+/*
+Placement of the in-game leaderboard panel of a map.
+Rank N is drawn at Origin + (N-1) * Spacing.
+*/
+type PanelLayout struct {
+	Origin  Vector `json:"origin"`
+	Angles  Vector `json:"angles"`
+	Spacing Vector `json:"spacing"`
+	Ranks   int    `json:"ranks"` // How many ranks are shown, at most 10
+}
+
+// Panels of the Movement HUB maps, used when MAP_PANELS_PATH is not set.
+var defaultPanels = map[string]PanelLayout{
+	"firstmap":    {Origin: Vector{0, -879, 40643.5}, Angles: Vector{0, -90, 0}, Spacing: Vector{0, 0, -50}, Ranks: 3},
+	"gymmap":      {Origin: Vector{879, 0, 40643.5}, Angles: Vector{0, 0, 0}, Spacing: Vector{0, 0, -50}, Ranks: 3},
+	"ithurtsmap":  {Origin: Vector{0, 879, 40643.5}, Angles: Vector{0, 90, 0}, Spacing: Vector{0, 0, -50}, Ranks: 3},
+	"strafeitmap": {Origin: Vector{-879, 0, 40643.5}, Angles: Vector{0, -180, 0}, Spacing: Vector{0, 0, -50}, Ranks: 3},
+}
+
+type RankRole struct {
+	MaxRank int    // Lowest leaderboard position that still earns the role
+	RoleID  string // ID of the Discord role to assign
+}
+
+/*
+A game server shown by the player counter.
+*/
+type WatchedServer struct {
+	Pattern   string // Server name in the R5R server list, * matches any text
+	ChannelID string // Voice channel renamed with the player count, empty to only list the server in the status message
+	Label     string // Prefix of the voice channel name
 }
 
 type Config struct {
 	DiscordBotToken       string
 	DiscordGuildID        string
 	BannedWords           string
+	ModRulesPath          string
+	ModLogChannelID       string
+	AppealChannelID       string
+	ModDryRun             bool
+	ModEscalationWindow   time.Duration
+	SpamDuplicateChannels int
+	SpamDuplicateWindow   time.Duration
+	SpamMaxMentions       int
+	SpamNewAccountAge     time.Duration
+	SpamTimeout           time.Duration
+	AnnounceLocation      *time.Location
 	PlayerCountChannelID  string
+	WatchedServers        []WatchedServer
+	ServerStatusChannelID string
+	LocalServerName       string
 	R5RServerListURL      string
 	UpdateInterval        time.Duration
 	LeaderboardsChannelID string
@@ -27,8 +83,28 @@ type Config struct {
 	NewRunsChannelID      string
 	NewRunsPath           string
 	Top10FilePath         string
+	Top10TemplatePath     string
+	Top10ReloadCommand    string
 	GamePath              string
+	ServerSupervisor      string
+	ServerCommand         string
+	ServerPIDFile         string
+	ServerSystemdUnit     string
+	ServerLogFile         string
+	ServerHeartbeatFile   string
+	ServerHeartbeatMaxAge time.Duration
+	ServerProbeAddress    string
+	RconAddress           string
+	RconPassword          string
+	RconKey               string
+	RconAllowedCommands   []string
+	RconAuditChannelID    string
+	RestartAfter          int
+	RestartBackoff        time.Duration
+	MaxRestartsPerHour    int
 	AdminIDs              []string
+	LeaderboardRoles      []RankRole
+	AlertsChannelID       string
 }
 
 func NewConfig() *Config {
@@ -42,28 +118,175 @@ func NewConfig() *Config {
 		updateInterval = 2 * time.Minute // Default to 2 minutes if not set or invalid.
 	}
 
+	restartAfter, err := strconv.Atoi(os.Getenv("SERVER_RESTART_AFTER"))
+	if err != nil || restartAfter < 1 {
+		restartAfter = 3 // Default to 3 failed checks in a row.
+	}
+	restartBackoff, err := time.ParseDuration(os.Getenv("SERVER_RESTART_BACKOFF"))
+	if err != nil || restartBackoff <= 0 {
+		restartBackoff = time.Minute // Default to 1 minute, doubled after each restart.
+	}
+	maxRestartsPerHour, err := strconv.Atoi(os.Getenv("SERVER_MAX_RESTARTS_PER_HOUR"))
+	if err != nil || maxRestartsPerHour < 1 {
+		maxRestartsPerHour = 4
+	}
+
+	heartbeatMaxAge, err := time.ParseDuration(os.Getenv("SERVER_HEARTBEAT_MAX_AGE"))
+	if err != nil || heartbeatMaxAge <= 0 {
+		heartbeatMaxAge = time.Minute
+	}
+
+	rconAllowedEnv := os.Getenv("RCON_ALLOWED_COMMANDS")
+	if rconAllowedEnv == "" {
+		rconAllowedEnv = "say,kick,map,status"
+	}
+	var rconAllowedCommands []string
+	for _, command := range strings.Split(rconAllowedEnv, ",") {
+		if command = strings.ToLower(strings.TrimSpace(command)); command != "" {
+			rconAllowedCommands = append(rconAllowedCommands, command)
+		}
+	}
+
+	modDryRun, _ := strconv.ParseBool(os.Getenv("MOD_DRY_RUN"))
+	modEscalationWindowStr := os.Getenv("MOD_ESCALATION_WINDOW")
+	modEscalationWindow, err := time.ParseDuration(modEscalationWindowStr)
+	if err != nil || modEscalationWindowStr == "" {
+		modEscalationWindow = 24 * time.Hour // Default to 24 hours, "0" disables escalation.
+	}
+
+	// The spam heuristics are on by default, "0" disables each of them.
+	spamDuplicateChannels, err := strconv.Atoi(os.Getenv("SPAM_DUPLICATE_CHANNELS"))
+	if err != nil || spamDuplicateChannels < 0 || spamDuplicateChannels == 1 {
+		spamDuplicateChannels = 3 // A single channel isn't a flood, 1 is as invalid as a negative count.
+	}
+	spamDuplicateWindow, err := time.ParseDuration(os.Getenv("SPAM_DUPLICATE_WINDOW"))
+	if err != nil || spamDuplicateWindow <= 0 {
+		spamDuplicateWindow = 30 * time.Second
+	}
+	spamMaxMentions, err := strconv.Atoi(os.Getenv("SPAM_MAX_MENTIONS"))
+	if err != nil || spamMaxMentions < 0 {
+		spamMaxMentions = 8
+	}
+	spamNewAccountDays, err := strconv.Atoi(os.Getenv("SPAM_NEW_ACCOUNT_DAYS"))
+	if err != nil || spamNewAccountDays < 0 {
+		spamNewAccountDays = 7
+	}
+	spamTimeout, err := time.ParseDuration(os.Getenv("SPAM_TIMEOUT"))
+	if err != nil || spamTimeout <= 0 {
+		spamTimeout = time.Hour
+	}
+
+	announceLocation := time.Local
+	if timezone := os.Getenv("ANNOUNCE_TIMEZONE"); timezone != "" {
+		announceLocation, err = time.LoadLocation(timezone)
+		if err != nil {
+			panic(fmt.Sprintf("Error loading ANNOUNCE_TIMEZONE: %v", err))
+		}
+	}
+
+	panels := defaultPanels
+	if panelsPath := os.Getenv("MAP_PANELS_PATH"); panelsPath != "" {
+		content, err := os.ReadFile(panelsPath)
+		if err != nil {
+			panic(fmt.Sprintf("Error loading map panels file: %v", err))
+		}
+		panels = make(map[string]PanelLayout)
+		if err := json.Unmarshal(content, &panels); err != nil {
+			panic(fmt.Sprintf("Error parsing map panels file: %v", err))
+		}
+	}
+
 	var allowedMaps []MapInfo
 	allowedMapsEnv := os.Getenv("ALLOWED_MAPS")
 	for _, mapInfo := range strings.Split(allowedMapsEnv, ",") {
 		parts := strings.Split(mapInfo, ":")
 		// Expects format map_name:message_id:channel_id
 		if len(parts) > 2 {
-			allowedMaps = append(allowedMaps, MapInfo{
+			mapInfo := MapInfo{
 				MapName:   parts[0],
 				MessageID: parts[1],
 				ChannelID: parts[2],
-			})
+			}
+			if panel, ok := panels[mapInfo.MapName]; ok {
+				panel.Ranks = min(max(panel.Ranks, 0), 10)
+				mapInfo.Panel = &panel
+			}
+			allowedMaps = append(allowedMaps, mapInfo)
 		}
 	}
 
 	adminsEnv := os.Getenv("ADMIN_IDS")
 	admins := strings.Split(adminsEnv, ",")
 
+	var leaderboardRoles []RankRole
+	for _, rankRole := range strings.Split(os.Getenv("LEADERBOARD_ROLES"), ",") {
+		parts := strings.Split(rankRole, ":")
+		// Expects format max_rank:role_id
+		if len(parts) != 2 {
+			continue
+		}
+		maxRank, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || maxRank < 1 {
+			continue
+		}
+		leaderboardRoles = append(leaderboardRoles, RankRole{
+			MaxRank: maxRank,
+			RoleID:  strings.TrimSpace(parts[1]),
+		})
+	}
+	sort.Slice(leaderboardRoles, func(i, j int) bool {
+		return leaderboardRoles[i].MaxRank < leaderboardRoles[j].MaxRank
+	})
+
+	var watchedServers []WatchedServer
+	for _, watchedServer := range strings.Split(os.Getenv("WATCHED_SERVERS"), ",") {
+		parts := strings.Split(watchedServer, ":")
+		// Expects format server_name_pattern[:channel_id[:label]]
+		pattern := strings.TrimSpace(parts[0])
+		if pattern == "" {
+			continue
+		}
+		server := WatchedServer{Pattern: pattern, Label: "Players online"}
+		if len(parts) > 1 {
+			server.ChannelID = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
+			server.Label = strings.TrimSpace(parts[2])
+		}
+		watchedServers = append(watchedServers, server)
+	}
+	localServerName := os.Getenv("LOCAL_SERVER_NAME")
+	if localServerName == "" {
+		localServerName = "[NA] MOVEMENT HUB"
+	}
+	// Older setups only had the single HUB channel.
+	if len(watchedServers) == 0 && os.Getenv("PLAYER_COUNT_CHANNEL_ID") != "" {
+		watchedServers = append(watchedServers, WatchedServer{
+			Pattern:   localServerName,
+			ChannelID: os.Getenv("PLAYER_COUNT_CHANNEL_ID"),
+			Label:     "Players online",
+		})
+	}
+
 	return &Config{
 		DiscordBotToken:       os.Getenv("DISCORD_BOT_TOKEN"),
 		DiscordGuildID:        os.Getenv("DISCORD_GUILD_ID"),
 		BannedWords:           os.Getenv("BANNED_WORDS"),
+		ModRulesPath:          os.Getenv("MOD_RULES_PATH"),
+		ModLogChannelID:       os.Getenv("MOD_LOG_CHANNEL_ID"),
+		AppealChannelID:       os.Getenv("APPEAL_CHANNEL_ID"),
+		ModDryRun:             modDryRun,
+		ModEscalationWindow:   modEscalationWindow,
+		SpamDuplicateChannels: spamDuplicateChannels,
+		SpamDuplicateWindow:   spamDuplicateWindow,
+		SpamMaxMentions:       spamMaxMentions,
+		SpamNewAccountAge:     time.Duration(spamNewAccountDays) * 24 * time.Hour,
+		SpamTimeout:           spamTimeout,
+		AnnounceLocation:      announceLocation,
 		PlayerCountChannelID:  os.Getenv("PLAYER_COUNT_CHANNEL_ID"),
+		WatchedServers:        watchedServers,
+		ServerStatusChannelID: os.Getenv("SERVER_STATUS_CHANNEL_ID"),
+		LocalServerName:       localServerName,
 		R5RServerListURL:      os.Getenv("R5R_SERVER_LIST_URL"),
 		UpdateInterval:        updateInterval,
 		LeaderboardsChannelID: os.Getenv("LEADERBOARDS_CHANNEL_ID"),
@@ -72,7 +295,27 @@ func NewConfig() *Config {
 		NewRunsChannelID:      os.Getenv("NEW_RUNS_CHANNEL_ID"),
 		NewRunsPath:           os.Getenv("NEW_RUNS_PATH"),
 		Top10FilePath:         os.Getenv("TOP_10_FILE_PATH"),
+		Top10TemplatePath:     os.Getenv("TOP_10_TEMPLATE_PATH"),
+		Top10ReloadCommand:    os.Getenv("TOP_10_RELOAD_COMMAND"),
 		GamePath:              os.Getenv("GAME_PATH"),
+		ServerSupervisor:      os.Getenv("SERVER_SUPERVISOR"),
+		ServerCommand:         os.Getenv("SERVER_COMMAND"),
+		ServerPIDFile:         os.Getenv("SERVER_PID_FILE"),
+		ServerSystemdUnit:     os.Getenv("SERVER_SYSTEMD_UNIT"),
+		ServerLogFile:         os.Getenv("SERVER_LOG_FILE"),
+		ServerHeartbeatFile:   os.Getenv("SERVER_HEARTBEAT_FILE"),
+		ServerHeartbeatMaxAge: heartbeatMaxAge,
+		ServerProbeAddress:    os.Getenv("SERVER_PROBE_ADDRESS"),
+		RconAddress:           os.Getenv("RCON_ADDRESS"),
+		RconPassword:          os.Getenv("RCON_PASSWORD"),
+		RconKey:               os.Getenv("RCON_KEY"),
+		RconAllowedCommands:   rconAllowedCommands,
+		RconAuditChannelID:    os.Getenv("RCON_AUDIT_CHANNEL_ID"),
+		RestartAfter:          restartAfter,
+		RestartBackoff:        restartBackoff,
+		MaxRestartsPerHour:    maxRestartsPerHour,
 		AdminIDs:              admins,
+		LeaderboardRoles:      leaderboardRoles,
+		AlertsChannelID:       os.Getenv("ALERTS_CHANNEL_ID"),
 	}
 }
@@ -0,0 +1,96 @@
+package discordbot
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+)
+
+// Discord accepts at most 25 autocomplete choices, each up to 100 characters long.
+const (
+	maxAutocompleteChoices = 25
+	maxChoiceLength        = 100
+)
+
+/*
+Answers autocomplete requests for player nicknames, map names, moderation rule names and link provider names.
+Player names, rules and providers come from the database, map names from the allowed maps list.
+*/
+func (b *Bot) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	options := data.Options
+	// The options of a subcommand are nested in it.
+	if len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		options = options[0].Options
+	}
+
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+		if opt.Focused {
+			focused = opt
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	var candidates []string
+	switch focused.Name {
+	case "nick", "nick_a", "nick_b", "old_nick":
+		var mapNames []string
+		for _, name := range []string{"map_name", "map"} {
+			if opt, ok := optionMap[name]; ok && helpers.IsValidTable(opt.StringValue(), b.Config.AllowedMaps) {
+				mapNames = append(mapNames, opt.StringValue())
+			}
+		}
+		candidates = helpers.PlayerNamesReader(b.DB, mapNames, b.Config.AllowedMaps)
+	case "map_name", "map":
+		for _, maap := range b.Config.AllowedMaps {
+			candidates = append(candidates, maap.MapName)
+		}
+		if data.Name == "zremove" {
+			candidates = append(candidates, "all")
+		}
+	case "name":
+		if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+			return
+		}
+		switch data.Name {
+		case "zfilter":
+			rules, _ := helpers.ModRulesReader(b.DB)
+			for _, rule := range rules {
+				candidates = append(candidates, rule.Name)
+			}
+		case "zlinkfix":
+			providers, _ := helpers.LinkProvidersReader(b.DB)
+			for _, provider := range providers {
+				candidates = append(candidates, provider.Name)
+			}
+		default:
+			return
+		}
+	default:
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range helpers.FuzzyMatch(focused.StringValue(), candidates, maxAutocompleteChoices) {
+		if len(name) > maxChoiceLength {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to autocomplete for %s: %v", data.Name, err)
+	}
+}
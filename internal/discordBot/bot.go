@@ -8,6 +8,8 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/bwmarrin/discordgo"
@@ -15,6 +17,10 @@ import (
 	"github.com/leonardomlouzas/GoldenSapling/internal/commands"
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
 	"github.com/leonardomlouzas/GoldenSapling/internal/helpers"
+	"github.com/leonardomlouzas/GoldenSapling/internal/linkfix"
+	"github.com/leonardomlouzas/GoldenSapling/internal/moderation"
+	"github.com/leonardomlouzas/GoldenSapling/internal/rcon"
+	"github.com/leonardomlouzas/GoldenSapling/internal/supervisor"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -22,12 +28,19 @@ type Bot struct {
 	Session       *discordgo.Session
 	Config        *config.Config
 	AutoBan       *automation.AutoBan
+	ModLog        *automation.ModLog
+	SpamGuard     *automation.SpamGuard
+	Appeals       *automation.Appeals
 	LinkFixer     *automation.LinkFixer
 	PlayerCounter *automation.PlayerCounter
-	TempMessenger *automation.TempMessenger
+	Watchdog      *automation.ServerWatchdog
+	RconConsole   *automation.RconConsole
+	Announcer     *automation.Announcer
 	Leaderboarder *automation.Leaderboard
 	NewRunners    *automation.NewRunners
 	FileUpdater   *automation.FileUpdater
+	RoleSync      *automation.RoleSync
+	Alerter       *automation.Alerter
 	DB            *sql.DB
 }
 
@@ -39,29 +52,115 @@ func (b *Bot) getCommands() []*discordgo.ApplicationCommand {
 		},
 		{
 			Name:        "leaderboard",
-			Description: "Displays the leaderboard for the current map post.",
+			Description: "Displays the leaderboard for a map, or a summary of every map.",
+			Options: []*discordgo.ApplicationCommandOption{
+				b.mapOption(),
+			},
 		},
 		{
 			Name:        "player_info",
-			Description: "Displays player information for the current map post.",
+			Description: "Displays player information for a map, or a summary of every map.",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "nick",
-					Description: "The player nickname.",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname.",
+					Required:     true,
+					Autocomplete: true,
 				},
+				b.mapOption(),
 			},
 		},
 		{
 			Name:        "last_runs",
-			Description: "Displays the last 10 runs of a player on the current map.",
+			Description: "Displays the last 10 runs of a player on a map, or a summary of every map.",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "nick",
-					Description: "The player nickname",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+				b.mapOption(),
+			},
+		},
+		{
+			Name:        "compare",
+			Description: "Compares two players head to head on every map.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick_a",
+					Description:  "The first player nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick_b",
+					Description:  "The second player nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+				b.mapOption(),
+			},
+		},
+		{
+			Name:        "profile",
+			Description: "Displays a player profile across every map.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname (defaults to your server nickname)",
+					Required:     false,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:        "progress",
+			Description: "Displays a chart of every run of a player on a map.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+				b.mapOption(),
+			},
+		},
+		{
+			Name:        "wr_history",
+			Description: "Displays every world record holder of a map and how long they held it.",
+			Options: []*discordgo.ApplicationCommandOption{
+				b.requiredMapOption(),
+			},
+		},
+		{
+			Name:        "population",
+			Description: "Displays a chart of the players online on a game server.",
+			Options: []*discordgo.ApplicationCommandOption{
+				b.periodOption(),
+				b.serverOption(),
+			},
+		},
+		{
+			Name:        "linkfix",
+			Description: "Turns the fixed link replies to your messages on or off.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Stops replying with fixed links to your messages",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "on",
+					Description: "Replies with fixed links to your messages again",
 				},
 			},
 		},
@@ -70,10 +169,11 @@ func (b *Bot) getCommands() []*discordgo.ApplicationCommand {
 			Description: "[ADMIN ONLY] Manually add a new run",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "nick",
-					Description: "The player nickname (case sensitive)",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname (case sensitive)",
+					Required:     true,
+					Autocomplete: true,
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
@@ -82,10 +182,11 @@ func (b *Bot) getCommands() []*discordgo.ApplicationCommand {
 					Required:    true,
 				},
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "map_name",
-					Description: "The player run map",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "map_name",
+					Description:  "The player run map",
+					Required:     true,
+					Autocomplete: true,
 				},
 			},
 		},
@@ -94,16 +195,18 @@ func (b *Bot) getCommands() []*discordgo.ApplicationCommand {
 			Description: "[ADMIN ONLY] Manually remove a specific run or all runs from a player",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "map_name",
-					Description: "The player run map ('all' for every map)",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "map_name",
+					Description:  "The player run map ('all' for every map)",
+					Required:     true,
+					Autocomplete: true,
 				},
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "nick",
-					Description: "The player nickname (case sensitive)",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname (case sensitive)",
+					Required:     true,
+					Autocomplete: true,
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
@@ -118,10 +221,11 @@ func (b *Bot) getCommands() []*discordgo.ApplicationCommand {
 			Description: "[ADMIN ONLY] Rename a player nickname to a new one",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "old_nick",
-					Description: "The old player nickname (case sensitive)",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "old_nick",
+					Description:  "The old player nickname (case sensitive)",
+					Required:     true,
+					Autocomplete: true,
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
@@ -131,7 +235,477 @@ func (b *Bot) getCommands() []*discordgo.ApplicationCommand {
 				},
 			},
 		},
+		{
+			Name:        "zlink",
+			Description: "[ADMIN ONLY] Link a Discord account to a player nickname for leaderboard roles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The Discord account",
+					Required:    true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "nick",
+					Description:  "The player nickname (case sensitive)",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:        "zunlink",
+			Description: "[ADMIN ONLY] Unlink a Discord account and remove its leaderboard roles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The Discord account",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "zserver",
+			Description: "[ADMIN ONLY] Check, restart or stop the game server",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Shows the game server state and its automatic restarts",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "restart",
+					Description: "Restarts the game server and turns automatic restarts back on",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "stop",
+					Description: "Stops the game server and turns automatic restarts off",
+				},
+			},
+		},
+		{
+			Name:        "zrcon",
+			Description: "[ADMIN ONLY] Run a console command on the game server",
+			Options: []*discordgo.ApplicationCommandOption{
+				rconSubCommand("say", "Sends a chat message to every player", "message", "The message"),
+				rconSubCommand("kick", "Kicks a player from the server", "nick", "The player nickname"),
+				rconSubCommand("map", "Changes the map", "map_name", "The map, e.g. mp_rr_canyonlands_staging"),
+				rconSubCommand("exec", "Runs an allowed console command", "command", "The command line"),
+			},
+		},
+		{
+			Name:        "zcase",
+			Description: "[ADMIN ONLY] Displays a moderation case",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "The case number",
+					Required:    true,
+					MinValue:    &minCaseID,
+				},
+			},
+		},
+		{
+			Name:        "zfilter",
+			Description: "[ADMIN ONLY] Manage the automatic moderation rules",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Adds a rule, it applies right away",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "The rule name, shown in the mod log",
+							Required:    true,
+							MaxLength:   100,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "pattern",
+							Description: "The word, text or regular expression to look for",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "action",
+							Description: "What happens to the author, the message is always deleted",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "delete", Value: moderation.ActionDelete.String()},
+								{Name: "timeout", Value: moderation.ActionTimeout.String()},
+								{Name: "kick", Value: moderation.ActionKick.String()},
+								{Name: "ban", Value: moderation.ActionBan.String()},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "match",
+							Description: "How the pattern is matched (defaults to word)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "word: whole words only", Value: string(moderation.MatchWord)},
+								{Name: "contains: anywhere in the message", Value: string(moderation.MatchContains)},
+								{Name: "normalized: ignores leetspeak, look-alike letters and separators", Value: string(moderation.MatchNormalized)},
+								{Name: "regex: Go regular expression", Value: string(moderation.MatchRegex)},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "timeout",
+							Description: "How long the timeout action lasts, e.g. 10m or 24h (defaults to 1h)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Removes a rule",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "The rule name",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Lists every rule",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "test",
+					Description: "Shows which rules a message would break, without acting on it",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "The sample message",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "zcases",
+			Description: "[ADMIN ONLY] Lists the moderation cases of a user",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The Discord account",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "zlinkfix",
+			Description: "[ADMIN ONLY] Manage the sites whose links get fixed",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Adds a provider, it applies right away",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "The provider name, e.g. the site",
+							Required:    true,
+							MaxLength:   100,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "pattern",
+							Description: "The Go regular expression matching the links",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "replacement",
+							Description: "The fixed link, with %s where the captured part goes",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "capture_group",
+							Description: "The group of the pattern put in the replacement (defaults to 1)",
+							Required:    false,
+							MinValue:    &minCaptureGroup,
+						},
+					},
+				},
+				linkProviderSubCommand("remove", "Removes a provider"),
+				linkProviderSubCommand("enable", "Turns a provider back on"),
+				linkProviderSubCommand("disable", "Stops fixing the links of a provider, keeping it"),
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "channel",
+					Description: "Allows or denies fixed links in a channel and its threads",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "The channel",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "While any channel is allowed, only the allowed channels get fixed links",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "allow", Value: helpers.LinkFixChannelAllow},
+								{Name: "deny", Value: helpers.LinkFixChannelDeny},
+								{Name: "reset", Value: "reset"},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Lists every provider",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "test",
+					Description: "Shows the links the bot would reply with, without replying",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "The sample message",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "zannounce",
+			Description: "[ADMIN ONLY] Manage the scheduled announcements",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Posts a message once or on a schedule",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "The channel the message is posted in",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "The message, \\n starts a new line",
+							Required:    true,
+							MaxLength:   2000,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "schedule",
+							Description: "Cron schedule of a recurring announcement, e.g. \"0 18 * * fri\" for Fridays at 18:00",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "at",
+							Description: "Time of a one-off announcement, e.g. \"2025-06-01 18:00\" or a delay like 2h",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "delete_after",
+							Description: "Deletes each post after this long, e.g. 30m or 24h (kept if empty)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Lists every announcement",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "delete",
+					Description: "Deletes an announcement, its posts are still deleted on time",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "The announcement number, see /zannounce list",
+							Required:    true,
+							MinValue:    &minAnnouncementID,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var (
+	minCaseID         float64 = 1
+	minCaptureGroup   float64 = 1
+	minAnnouncementID float64 = 1
+)
+
+func linkProviderSubCommand(name, description string) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        name,
+		Description: description,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "name",
+				Description:  "The provider name",
+				Required:     true,
+				Autocomplete: true,
+			},
+		},
+	}
+}
+
+func rconSubCommand(name, description, optionName, optionDescription string) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        name,
+		Description: description,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        optionName,
+				Description: optionDescription,
+				Required:    true,
+			},
+		},
+	}
+}
+
+/*
+Builds the optional map option shared by the statistics commands.
+When omitted, the map is detected from the channel the command was used in.
+*/
+func (b *Bot) mapOption() *discordgo.ApplicationCommandOption {
+	option := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "map",
+		Description: "The map (defaults to the current map channel)",
+		Required:    false,
+	}
+	// Discord accepts at most 25 choices per option, longer map lists are autocompleted instead.
+	if len(b.Config.AllowedMaps) > maxAutocompleteChoices {
+		option.Autocomplete = true
+		return option
+	}
+	for _, maap := range b.Config.AllowedMaps {
+		option.Choices = append(option.Choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  maap.MapName,
+			Value: maap.MapName,
+		})
+	}
+	return option
+}
+
+/*
+Builds the optional period option of the population command.
+*/
+func (b *Bot) periodOption() *discordgo.ApplicationCommandOption {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, period := range commands.PopulationPeriods {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  period,
+			Value: period,
+		})
+	}
+
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "period",
+		Description: "How far back to look (defaults to day)",
+		Required:    false,
+		Choices:     choices,
+	}
+}
+
+/*
+Builds the optional server option with every watched game server as a choice.
+*/
+func (b *Bot) serverOption() *discordgo.ApplicationCommandOption {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, server := range b.Config.WatchedServers {
+		// Discord accepts at most 25 choices per option.
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  server.Pattern,
+			Value: server.Pattern,
+		})
+	}
+
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "server",
+		Description: "The game server (defaults to the first watched server)",
+		Required:    false,
+		Choices:     choices,
+	}
+}
+
+/*
+Builds a map option that has to be filled, for commands without a channel fallback.
+*/
+func (b *Bot) requiredMapOption() *discordgo.ApplicationCommandOption {
+	option := b.mapOption()
+	option.Description = "The map"
+	option.Required = true
+	return option
+}
+
+/*
+Resolves the map a statistics command refers to.
+The explicit map option wins, then the map channel the command was used in.
+Returns an empty string when neither applies.
+*/
+func (b *Bot) resolveMapName(s *discordgo.Session, i *discordgo.InteractionCreate, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) string {
+	if opt, ok := optionMap["map"]; ok {
+		mapName := helpers.MapNameNormalizer(opt.StringValue())
+		if helpers.IsValidTable(mapName, b.Config.AllowedMaps) {
+			return mapName
+		}
+	}
+
+	channel, err := s.Channel(i.ChannelID)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to get channel %s while resolving map name: %v", i.ChannelID, err)
+		return ""
+	}
+
+	mapName := helpers.MapNameNormalizer(channel.Name)
+	if !helpers.IsValidTable(mapName, b.Config.AllowedMaps) {
+		return ""
 	}
+	return mapName
 }
 
 /*
@@ -178,13 +752,37 @@ func New(cfg *config.Config) (*Bot, error) {
 	}
 	db.SetMaxOpenConns(1)
 
-	autoBanService := automation.NewAutoBan(cfg)
-	tempMessengerService := automation.NewTempMessenger()
-	playerCounterService := automation.NewPlayerCounter(dg, cfg)
-	leaderboardService := automation.NewLeaderboardUpdater(dg, db, cfg)
+	if err := helpers.MigrateDB(db, cfg.AllowedMaps); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	// Catches up with runs changed while the bot was offline.
+	helpers.RebuildWRHistories(db, "all", cfg.AllowedMaps)
+
+	serverSupervisor, err := supervisor.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create game server supervisor: %w", err)
+	}
+
+	modLogService := automation.NewModLog(dg, db, cfg)
+	appealsService := automation.NewAppeals(dg, db, cfg)
+	autoBanService, err := automation.NewAutoBan(cfg, db, modLogService, appealsService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AutoBan service: %w", err)
+	}
+	spamGuardService := automation.NewSpamGuard(cfg, modLogService)
+	announcerService := automation.NewAnnouncer(dg, db, cfg)
+	alerterService := automation.NewAlerter(dg, cfg)
+	watchdogService := automation.NewServerWatchdog(serverSupervisor, alerterService, cfg)
+	playerCounterService := automation.NewPlayerCounter(dg, db, cfg, watchdogService)
+	roleSyncService := automation.NewRoleSync(dg, db, cfg)
+	leaderboardService := automation.NewLeaderboardUpdater(dg, db, cfg, roleSyncService)
 	newRunsSevice := automation.NewRunnersService(dg, db, cfg)
-	fileUpdaterService := automation.NewFileUpdater(dg, db, cfg)
-	linkFixerService, err := automation.NewLinkFixer()
+	fileUpdaterService := automation.NewFileUpdater(dg, db, cfg, alerterService)
+	rconConsoleService, err := automation.NewRconConsole(dg, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RCON console: %w", err)
+	}
+	linkFixerService, err := automation.NewLinkFixer(db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create LinkFixer service: %w", err)
 	}
@@ -194,12 +792,19 @@ func New(cfg *config.Config) (*Bot, error) {
 		Config:        cfg,
 		DB:            db,
 		AutoBan:       autoBanService,
+		ModLog:        modLogService,
+		SpamGuard:     spamGuardService,
+		Appeals:       appealsService,
 		LinkFixer:     linkFixerService,
 		PlayerCounter: playerCounterService,
-		TempMessenger: tempMessengerService,
+		Watchdog:      watchdogService,
+		RconConsole:   rconConsoleService,
+		Announcer:     announcerService,
 		Leaderboarder: leaderboardService,
 		NewRunners:    newRunsSevice,
 		FileUpdater:   fileUpdaterService,
+		RoleSync:      roleSyncService,
+		Alerter:       alerterService,
 	}, nil
 }
 
@@ -272,10 +877,16 @@ func (b *Bot) Run() {
 	b.Session.AddHandler(b.ready)
 	b.Session.AddHandler(b.interactionCreate)
 	b.Session.AddHandler(b.AutoBan.MessageCreateHandler)
+	b.Session.AddHandler(b.SpamGuard.MessageCreateHandler)
+	b.Session.AddHandler(b.ModLog.AuditLogHandler)
 	b.Session.AddHandler(b.LinkFixer.MessageCreateHandler)
-	b.Session.AddHandler(b.TempMessenger.MessageCreateHandler)
+	b.Session.AddHandler(b.LinkFixer.MessageUpdateHandler)
+	b.Session.AddHandler(b.LinkFixer.MessageDeleteHandler)
+	b.Session.AddHandler(b.LinkFixer.MessageDeleteBulkHandler)
+	b.Session.AddHandler(b.LinkFixer.MessageReactionAddHandler)
 
-	b.Session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages
+	// Audit log entries are sent with the guild bans intent.
+	b.Session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsGuildBans | discordgo.IntentsGuildMessageReactions
 
 	err := b.Session.Open()
 	if err != nil {
@@ -301,16 +912,91 @@ func (b *Bot) ready(s *discordgo.Session, event *discordgo.Ready) {
 	s.UpdateGameStatus(0, "Managing Movement HUB")
 	b.syncAndCleanCommands()
 	b.PlayerCounter.Start()
+	b.Watchdog.Start()
 	b.Leaderboarder.Start()
 	b.NewRunners.Start()
 	b.FileUpdater.Start()
+	b.Announcer.Start()
 	log.Println("[DISCORD] Bot is ready!")
 }
 
 /*
-Handles interaction events, such as slash commands.
+Handles interaction events, such as slash commands, their autocomplete requests, buttons and forms.
 */
 func (b *Bot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.handleApplicationCommand(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.handleAutocomplete(s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleMessageComponent(s, i)
+	case discordgo.InteractionModalSubmit:
+		b.handleModalSubmit(s, i)
+	}
+}
+
+/*
+Routes button presses to their handlers, buttons carry the case they act on in their custom ID.
+*/
+func (b *Bot) handleMessageComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	prefix, caseID, ok := parseCaseCustomID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+
+	switch prefix {
+	case automation.AppealButtonID:
+		b.Appeals.HandleAppealButton(s, i, caseID)
+	case automation.AppealUnbanID, automation.AppealKeepID:
+		if i.Member == nil || !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+			err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "Nice try, only admins can review appeals.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			if err != nil {
+				log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+			}
+			return
+		}
+		b.Appeals.HandleReview(s, i, caseID, prefix == automation.AppealUnbanID)
+	}
+}
+
+func (b *Bot) handleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	prefix, caseID, ok := parseCaseCustomID(i.ModalSubmitData().CustomID)
+	if !ok {
+		return
+	}
+
+	switch prefix {
+	case automation.AppealFormID:
+		b.Appeals.HandleAppealForm(s, i, caseID)
+	}
+}
+
+/*
+Splits a custom ID into its prefix and case number.
+*/
+func parseCaseCustomID(customID string) (string, int64, bool) {
+	prefix, id, ok := strings.Cut(customID, ":")
+	if !ok {
+		return "", 0, false
+	}
+	caseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return prefix, caseID, true
+}
+
+/*
+Routes slash commands to their handlers.
+*/
+func (b *Bot) handleApplicationCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	switch i.ApplicationCommandData().Name {
 	case "help":
 		b.handleHelpCommand(s, i)
@@ -320,12 +1006,42 @@ func (b *Bot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCr
 		b.handlePlayerInfoCommand(s, i)
 	case "last_runs":
 		b.handleLastRunsCommand(s, i)
+	case "compare":
+		b.handleCompareCommand(s, i)
+	case "profile":
+		b.handleProfileCommand(s, i)
+	case "progress":
+		b.handleProgressCommand(s, i)
+	case "wr_history":
+		b.handleWRHistoryCommand(s, i)
+	case "population":
+		b.handlePopulationCommand(s, i)
+	case "linkfix":
+		b.handleLinkFixOptOutCommand(s, i)
 	case "zadd":
 		b.handleAddCommand(s, i)
 	case "zremove":
 		b.handleRemoveCommand(s, i)
 	case "zrename":
 		b.handleRenameCommand(s, i)
+	case "zlink":
+		b.handleLinkCommand(s, i)
+	case "zunlink":
+		b.handleUnlinkCommand(s, i)
+	case "zserver":
+		b.handleServerCommand(s, i)
+	case "zrcon":
+		b.handleRconCommand(s, i)
+	case "zcase":
+		b.handleCaseCommand(s, i)
+	case "zcases":
+		b.handleCasesCommand(s, i)
+	case "zfilter":
+		b.handleFilterCommand(s, i)
+	case "zlinkfix":
+		b.handleLinkFixCommand(s, i)
+	case "zannounce":
+		b.handleAnnounceCommand(s, i)
 	}
 }
 
@@ -344,33 +1060,23 @@ func (b *Bot) handleHelpCommand(s *discordgo.Session, i *discordgo.InteractionCr
 }
 
 func (b *Bot) handleLeaderboardCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	channel, err := s.Channel(i.ChannelID)
-	if err != nil {
-		log.Printf("[DISCORD] Failed to get channel: %v", err)
-		return
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
 	}
-	mapName := channel.Name
-	mapName = helpers.MapNameNormalizer(mapName)
-	if !helpers.IsValidTable(mapName, b.Config.AllowedMaps) {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "This command can only be used in movement map channels.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		if err != nil {
-			log.Printf("[DISCORD] Failed to send ephemeral message for wrong channel: %v", err)
-		}
-		return
+
+	data := &discordgo.InteractionResponseData{}
+	mapName := b.resolveMapName(s, i, optionMap)
+	if mapName == "" {
+		data.Embeds = []*discordgo.MessageEmbed{commands.LeaderboardSummary(b.DB, b.Config.AllowedMaps)}
+	} else {
+		data.Content = commands.LeaderboardByMapName(b.DB, mapName, b.Config.AllowedMaps)
 	}
 
-	content := commands.LeaderboardByMapName(b.DB, mapName, b.Config.AllowedMaps)
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: content,
-		},
+		Data: data,
 	})
 	if err != nil {
 		log.Printf("[DISCORD] Failed to respond to leaderboard command: %v", err)
@@ -387,83 +1093,258 @@ func (b *Bot) handlePlayerInfoCommand(s *discordgo.Session, i *discordgo.Interac
 
 	playerName := optionMap["nick"].StringValue()
 
-	channel, err := s.Channel(i.ChannelID)
+	var embed *discordgo.MessageEmbed
+	mapName := b.resolveMapName(s, i, optionMap)
+	if mapName == "" {
+		embed = commands.PlayerInfoSummary(b.DB, playerName, b.Config.AllowedMaps)
+	} else {
+		embed = commands.PlayerInfo(b.DB, playerName, mapName, b.Config.AllowedMaps)
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
 	if err != nil {
-		log.Printf("[DISCORD] Failed to get channel while handling PlayerInfoCommand: %v", err)
+		log.Printf("[DISCORD] Failed to respond to PlayerInfoCommand: %v", err)
 		return
 	}
+}
 
-	mapName := channel.Name
-	mapName = helpers.MapNameNormalizer(mapName)
-	if !helpers.IsValidTable(mapName, b.Config.AllowedMaps) {
+func (b *Bot) handleLastRunsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	playerName := optionMap["nick"].StringValue()
+
+	data := &discordgo.InteractionResponseData{}
+	mapName := b.resolveMapName(s, i, optionMap)
+	if mapName == "" {
+		data.Embeds = []*discordgo.MessageEmbed{commands.LastRunsSummary(b.DB, playerName, b.Config.AllowedMaps)}
+	} else {
+		data.Content = commands.LastRuns(b.DB, playerName, mapName, b.Config.AllowedMaps)
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to LastRun command: %v", err)
+		return
+	}
+
+}
+
+func (b *Bot) handleCompareCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	playerA := optionMap["nick_a"].StringValue()
+	playerB := optionMap["nick_b"].StringValue()
+
+	// Unlike the other stats commands, no map means every map here.
+	var mapName string
+	if opt, ok := optionMap["map"]; ok {
+		mapName = helpers.MapNameNormalizer(opt.StringValue())
+	}
+
+	embed := commands.ComparePlayers(b.DB, playerA, playerB, mapName, b.Config.AllowedMaps)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to compare command: %v", err)
+	}
+}
+
+func (b *Bot) handleProfileCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	var playerName string
+	if opt, ok := optionMap["nick"]; ok {
+		playerName = opt.StringValue()
+	} else {
+		playerName = interactionDisplayName(i)
+	}
+
+	embed, file := commands.PlayerProfile(b.DB, playerName, b.Config.AllowedMaps)
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	}
+	if file != nil {
+		data.Files = []*discordgo.File{file}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to profile command: %v", err)
+	}
+}
+
+/*
+Returns the name the user goes by where the interaction happened, the server nickname when there is one.
+*/
+func interactionDisplayName(i *discordgo.InteractionCreate) string {
+	user := i.User
+	if i.Member != nil {
+		if i.Member.Nick != "" {
+			return i.Member.Nick
+		}
+		user = i.Member.User
+	}
+	if user == nil {
+		return ""
+	}
+	if user.GlobalName != "" {
+		return user.GlobalName
+	}
+	return user.Username
+}
+
+func (b *Bot) handleProgressCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	playerName := optionMap["nick"].StringValue()
+
+	mapName := b.resolveMapName(s, i, optionMap)
+	if mapName == "" {
 		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "This command can only be used in movement map channels.",
+				Content: "Pick a map with the map option or use this command in a movement map channel.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
 		if err != nil {
-			log.Printf("[DISCORD] Failed to send ephemeral message for wrong channel while handling PlayerInfoCommand: %v", err)
+			log.Printf("[DISCORD] Failed to send ephemeral message for missing map: %v", err)
 		}
 		return
 	}
 
-	embed := commands.PlayerInfo(b.DB, playerName, mapName, b.Config.AllowedMaps)
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	embed, file := commands.PlayerProgress(b.DB, playerName, mapName, b.Config.AllowedMaps)
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	}
+	if file != nil {
+		data.Files = []*discordgo.File{file}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
-		},
+		Data: data,
 	})
 	if err != nil {
-		log.Printf("[DISCORD] Failed to respond to PlayerInfoCommand: %v", err)
-		return
+		log.Printf("[DISCORD] Failed to respond to progress command: %v", err)
 	}
 }
 
-func (b *Bot) handleLastRunsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handlePopulationCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	options := i.ApplicationCommandData().Options
 	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
 	for _, opt := range options {
 		optionMap[opt.Name] = opt
 	}
 
-	playerName := optionMap["nick"].StringValue()
-
-	channel, err := s.Channel(i.ChannelID)
-	if err != nil {
-		log.Printf("[DISCORD] Failed to get channel: %v", err)
-		return
-	}
-	mapName := channel.Name
-	mapName = helpers.MapNameNormalizer(mapName)
-	if !helpers.IsValidTable(mapName, b.Config.AllowedMaps) {
+	if len(b.Config.WatchedServers) == 0 {
 		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "This command can only be used in movement map channels.",
+				Content: "No game server is being watched, player counts are not recorded.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
 		if err != nil {
-			log.Printf("[DISCORD] Failed to send ephemeral message for wrong channel: %v", err)
+			log.Printf("[DISCORD] Failed to send ephemeral message for missing watched servers: %v", err)
 		}
 		return
 	}
 
-	content := commands.LastRuns(b.DB, playerName, mapName, b.Config.AllowedMaps)
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	period := "day"
+	if opt, ok := optionMap["period"]; ok {
+		period = opt.StringValue()
+	}
+	serverName := b.Config.WatchedServers[0].Pattern
+	if opt, ok := optionMap["server"]; ok {
+		serverName = opt.StringValue()
+	}
+
+	embed, file := commands.Population(b.DB, serverName, period)
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	}
+	if file != nil {
+		data.Files = []*discordgo.File{file}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to population command: %v", err)
+	}
+}
+
+func (b *Bot) handleLinkFixOptOutCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subCommand := i.ApplicationCommandData().Options[0]
+
+	embed := commands.SetLinkFixOptOut(b.DB, i.Member.User.ID, subCommand.Name == "off")
+	b.reloadLinkFixSettings(i.Member.User.Username)
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: content,
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
 		},
 	})
 	if err != nil {
-		log.Printf("[DISCORD] Failed to respond to LastRun command: %v", err)
-		return
+		log.Printf("[DISCORD] Failed to respond to linkfix %s command: %v", subCommand.Name, err)
+	}
+}
+
+func (b *Bot) handleWRHistoryCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
 	}
 
+	mapName := helpers.MapNameNormalizer(optionMap["map"].StringValue())
+
+	embed := commands.WRHistory(b.DB, mapName, b.Config.AllowedMaps)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to wr_history command: %v", err)
+	}
 }
 
 func (b *Bot) handleAddCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -579,3 +1460,468 @@ func (b *Bot) handleRenameCommand(s *discordgo.Session, i *discordgo.Interaction
 		log.Printf("[DISCORD] Failed to remove run(s): %v", err)
 	}
 }
+
+func (b *Bot) handleLinkCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	user := optionMap["user"].UserValue(s)
+	playerName := optionMap["nick"].StringValue()
+
+	content := commands.LinkPlayer(b.DB, user.ID, playerName)
+	// Applies the roles right away instead of waiting for the next leaderboard update.
+	go b.RoleSync.Sync()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{content},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to link command: %v", err)
+	}
+}
+
+func (b *Bot) handleUnlinkCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	user := optionMap["user"].UserValue(s)
+
+	content := commands.UnlinkPlayer(b.DB, user.ID)
+	// Runs in the background as it can wait on a sync in progress, and interactions must be answered quickly.
+	go func() {
+		if err := b.RoleSync.RevokeAll(user.ID); err != nil {
+			log.Printf("[DISCORD] Failed to revoke leaderboard roles from %s: %v", user.ID, err)
+		}
+	}()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{content},
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to unlink command: %v", err)
+	}
+}
+
+func (b *Bot) handleServerCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	action := i.ApplicationCommandData().Options[0].Name
+
+	// Stopping the server can take longer than Discord waits for a response,
+	// and the status waits for a stop or restart in progress.
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to defer server %s command: %v", action, err)
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch action {
+	case "status":
+		embed = commands.ServerStatus(b.Watchdog.Status())
+	case "stop":
+		log.Printf("[DISCORD] %s requested a game server %s", i.Member.User.Username, action)
+		embed = commands.ServerAction(action, b.Watchdog.Stop())
+	default:
+		log.Printf("[DISCORD] %s requested a game server %s", i.Member.User.Username, action)
+		embed = commands.ServerAction(action, b.Watchdog.Restart())
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to server %s command: %v", action, err)
+	}
+}
+
+func (b *Bot) handleRconCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	if b.RconConsole == nil {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "RCON is not configured, set RCON_ADDRESS to use this command.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send ephemeral message for missing RCON: %v", err)
+		}
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	value := subCommand.Options[0].StringValue()
+	var line string
+	switch subCommand.Name {
+	case "say":
+		line = "say " + rcon.Quote(value)
+	case "kick":
+		line = "kick " + rcon.Quote(value)
+	case "map":
+		line = "map " + rcon.Quote(value)
+	default:
+		line = strings.TrimSpace(value)
+	}
+
+	// Connecting to the server can take longer than Discord waits for a response.
+	// The console output can hold player IPs, only the admin sees it.
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to defer rcon command: %v", err)
+		return
+	}
+
+	output, err := b.RconConsole.Run(i.Member.User.ID, line)
+	embed := commands.RconResult(line, output, err)
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to rcon command: %v", err)
+	}
+}
+
+func (b *Bot) handleCaseCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	embed := commands.ModCase(b.DB, optionMap["id"].IntValue())
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to case command: %v", err)
+	}
+}
+
+func (b *Bot) handleCasesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	user := optionMap["user"].UserValue(s)
+
+	embed := commands.UserModCases(b.DB, user)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to cases command: %v", err)
+	}
+}
+
+func (b *Bot) handleFilterCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subCommand.Options))
+	for _, opt := range subCommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch subCommand.Name {
+	case "add":
+		rule := moderation.Rule{
+			Name:    strings.TrimSpace(optionMap["name"].StringValue()),
+			Match:   moderation.MatchWord,
+			Pattern: optionMap["pattern"].StringValue(),
+			Action:  optionMap["action"].StringValue(),
+		}
+		if opt, ok := optionMap["match"]; ok {
+			rule.Match = moderation.MatchKind(opt.StringValue())
+		}
+		if opt, ok := optionMap["timeout"]; ok {
+			rule.Timeout = strings.TrimSpace(opt.StringValue())
+		}
+		embed = commands.AddFilter(b.DB, rule, i.Member.User.ID)
+		b.reloadModerationRules(i.Member.User.Username)
+	case "remove":
+		embed = commands.RemoveFilter(b.DB, optionMap["name"].StringValue())
+		b.reloadModerationRules(i.Member.User.Username)
+	case "list":
+		embed = commands.FilterList(b.DB)
+	case "test":
+		message := optionMap["message"].StringValue()
+		embed = commands.FilterTest(message, b.AutoBan.Test(message), b.Config.ModDryRun)
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to filter %s command: %v", subCommand.Name, err)
+	}
+}
+
+func (b *Bot) reloadModerationRules(changedBy string) {
+	if err := b.AutoBan.ReloadRules(); err != nil {
+		log.Printf("[DISCORD] Failed to reload moderation rules changed by %s: %v", changedBy, err)
+		return
+	}
+	log.Printf("[DISCORD] Moderation rules changed by %s, %d rules active", changedBy, b.AutoBan.RuleCount())
+}
+
+func (b *Bot) handleLinkFixCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subCommand.Options))
+	for _, opt := range subCommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch subCommand.Name {
+	case "add":
+		provider := linkfix.Provider{
+			Name:         strings.TrimSpace(optionMap["name"].StringValue()),
+			Pattern:      optionMap["pattern"].StringValue(),
+			Replacement:  strings.TrimSpace(optionMap["replacement"].StringValue()),
+			CaptureGroup: 1,
+			Enabled:      true,
+		}
+		if opt, ok := optionMap["capture_group"]; ok {
+			provider.CaptureGroup = int(opt.IntValue())
+		}
+		embed = commands.AddLinkProvider(b.DB, provider)
+		b.reloadLinkProviders(i.Member.User.Username)
+	case "remove":
+		embed = commands.RemoveLinkProvider(b.DB, optionMap["name"].StringValue())
+		b.reloadLinkProviders(i.Member.User.Username)
+	case "enable", "disable":
+		embed = commands.SetLinkProviderEnabled(b.DB, optionMap["name"].StringValue(), subCommand.Name == "enable")
+		b.reloadLinkProviders(i.Member.User.Username)
+	case "channel":
+		channel := optionMap["channel"].ChannelValue(s)
+		mode := optionMap["mode"].StringValue()
+		if mode == "reset" {
+			mode = ""
+		}
+		embed = commands.SetLinkFixChannel(b.DB, channel.ID, mode, i.Member.User.ID)
+		b.reloadLinkFixSettings(i.Member.User.Username)
+	case "list":
+		embed = commands.LinkProviderList(b.DB)
+	case "test":
+		message := optionMap["message"].StringValue()
+		embed = commands.LinkFixTest(message, b.LinkFixer.Test(message))
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to linkfix %s command: %v", subCommand.Name, err)
+	}
+}
+
+func (b *Bot) reloadLinkProviders(changedBy string) {
+	if err := b.LinkFixer.ReloadProviders(); err != nil {
+		log.Printf("[DISCORD] Failed to reload link providers changed by %s: %v", changedBy, err)
+		return
+	}
+	log.Printf("[DISCORD] Link providers changed by %s", changedBy)
+}
+
+func (b *Bot) reloadLinkFixSettings(changedBy string) {
+	if err := b.LinkFixer.ReloadSettings(); err != nil {
+		log.Printf("[DISCORD] Failed to reload link fixing settings changed by %s: %v", changedBy, err)
+	}
+}
+
+func (b *Bot) handleAnnounceCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !helpers.IsAdmin(i.Member.User.ID, b.Config.AdminIDs) {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Nice try, only admins can use this command.",
+			},
+		})
+		if err != nil {
+			log.Printf("[DISCORD] Failed to send permission denied message: %v", err)
+		}
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(subCommand.Options))
+	for _, opt := range subCommand.Options {
+		optionMap[opt.Name] = opt
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch subCommand.Name {
+	case "create":
+		announcement := &helpers.Announcement{
+			ChannelID: optionMap["channel"].ChannelValue(s).ID,
+			Content:   strings.ReplaceAll(optionMap["message"].StringValue(), `\n`, "\n"),
+			CreatedBy: i.Member.User.ID,
+		}
+		if opt, ok := optionMap["schedule"]; ok {
+			announcement.Schedule = strings.TrimSpace(opt.StringValue())
+		}
+		var at string
+		if opt, ok := optionMap["at"]; ok {
+			at = strings.TrimSpace(opt.StringValue())
+		}
+		var deleteAfter string
+		if opt, ok := optionMap["delete_after"]; ok {
+			deleteAfter = strings.TrimSpace(opt.StringValue())
+		}
+		embed = commands.CreateAnnouncement(b.DB, announcement, at, deleteAfter, b.Config.AnnounceLocation)
+	case "list":
+		embed = commands.AnnouncementList(b.DB)
+	case "delete":
+		embed = commands.DeleteAnnouncement(b.DB, optionMap["id"].IntValue())
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("[DISCORD] Failed to respond to announce %s command: %v", subCommand.Name, err)
+	}
+}
@@ -0,0 +1,174 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+/*
+A message posted by the bot once or on a cron schedule.
+*/
+type Announcement struct {
+	ID        int64
+	ChannelID string
+	Content   string
+	Schedule  string // Cron expression, empty for one-off announcements
+	NextRun   time.Time
+	TTL       time.Duration // How long each post stays before it is deleted, 0 keeps it
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+/*
+A posted announcement waiting to be deleted.
+*/
+type AnnouncementPost struct {
+	MessageID string
+	ChannelID string
+}
+
+const announcementColumns = `id, channel_id, content, schedule, next_run, ttl, created_by, created_at`
+
+/*
+Stores a new announcement and returns its number.
+*/
+func CreateAnnouncement(db *sql.DB, announcement *Announcement) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO announcements (channel_id, content, schedule, next_run, ttl, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		announcement.ChannelID, announcement.Content, announcement.Schedule, announcement.NextRun.Unix(),
+		int64(announcement.TTL.Seconds()), announcement.CreatedBy, announcement.CreatedAt.Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to store announcement for channel %s: %v", announcement.ChannelID, err)
+		return 0, errors.New("failed to store announcement")
+	}
+	return result.LastInsertId()
+}
+
+/*
+Removes an announcement by its number, returning false when there was none.
+Posts already sent are still deleted when their TTL ends.
+*/
+func DeleteAnnouncement(db *sql.DB, id int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM announcements WHERE id = ?`, id)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to delete announcement %d: %v", id, err)
+		return false, errors.New("failed to delete announcement")
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+/*
+Sets when a recurring announcement is posted next.
+*/
+func SetAnnouncementNextRun(db *sql.DB, id int64, nextRun time.Time) error {
+	_, err := db.Exec(`UPDATE announcements SET next_run = ? WHERE id = ?`, nextRun.Unix(), id)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to update next run of announcement %d: %v", id, err)
+		return errors.New("failed to update announcement")
+	}
+	return nil
+}
+
+/*
+Returns every announcement, the next to be posted first.
+*/
+func AnnouncementsReader(db *sql.DB) ([]Announcement, error) {
+	rows, err := db.Query(`SELECT ` + announcementColumns + ` FROM announcements ORDER BY next_run, id`)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve announcements: %v", err)
+		return nil, errors.New("failed to retrieve announcements")
+	}
+	defer rows.Close()
+	return scanAnnouncements(rows)
+}
+
+/*
+Returns the announcements that should have been posted by now.
+*/
+func DueAnnouncementsReader(db *sql.DB, now time.Time) ([]Announcement, error) {
+	rows, err := db.Query(`SELECT `+announcementColumns+` FROM announcements WHERE next_run <= ? ORDER BY next_run, id`, now.Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve due announcements: %v", err)
+		return nil, errors.New("failed to retrieve announcements")
+	}
+	defer rows.Close()
+	return scanAnnouncements(rows)
+}
+
+func scanAnnouncements(rows *sql.Rows) ([]Announcement, error) {
+	var announcements []Announcement
+	for rows.Next() {
+		var announcement Announcement
+		var nextRun, ttl, createdAt int64
+		err := rows.Scan(&announcement.ID, &announcement.ChannelID, &announcement.Content, &announcement.Schedule,
+			&nextRun, &ttl, &announcement.CreatedBy, &createdAt)
+		if err != nil {
+			log.Printf("[DISCORD] Failed to scan announcement: %v", err)
+			continue
+		}
+		announcement.NextRun = time.Unix(nextRun, 0)
+		announcement.TTL = time.Duration(ttl) * time.Second
+		announcement.CreatedAt = time.Unix(createdAt, 0)
+		announcements = append(announcements, announcement)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving announcements: %v", err)
+		return nil, errors.New("failed to retrieve announcements")
+	}
+	return announcements, nil
+}
+
+/*
+Remembers a posted announcement so it is deleted at deleteAt, even after a restart.
+*/
+func AddAnnouncementPost(db *sql.DB, channelID, messageID string, deleteAt time.Time) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO announcement_posts (message_id, channel_id, delete_at) VALUES (?, ?, ?)`,
+		messageID, channelID, deleteAt.Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to store announcement post %s: %v", messageID, err)
+		return errors.New("failed to store announcement post")
+	}
+	return nil
+}
+
+func RemoveAnnouncementPost(db *sql.DB, messageID string) error {
+	_, err := db.Exec(`DELETE FROM announcement_posts WHERE message_id = ?`, messageID)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to remove announcement post %s: %v", messageID, err)
+		return errors.New("failed to remove announcement post")
+	}
+	return nil
+}
+
+/*
+Returns the posted announcements whose TTL has ended.
+*/
+func ExpiredAnnouncementPostsReader(db *sql.DB, now time.Time) ([]AnnouncementPost, error) {
+	rows, err := db.Query(`SELECT message_id, channel_id FROM announcement_posts WHERE delete_at <= ?`, now.Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve expired announcement posts: %v", err)
+		return nil, errors.New("failed to retrieve announcement posts")
+	}
+	defer rows.Close()
+
+	var posts []AnnouncementPost
+	for rows.Next() {
+		var post AnnouncementPost
+		if err := rows.Scan(&post.MessageID, &post.ChannelID); err != nil {
+			log.Printf("[DISCORD] Failed to scan announcement post: %v", err)
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving announcement posts: %v", err)
+		return nil, errors.New("failed to retrieve announcement posts")
+	}
+	return posts, nil
+}
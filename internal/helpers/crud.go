@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
 )
@@ -14,8 +15,8 @@ func AddRunToTable(db *sql.DB, mapName, playerName string, timerInt int, allowed
 		return errors.New("invalid table")
 	}
 	_, err := db.Exec(
-		fmt.Sprintf(`INSERT INTO "%s" (player_name, time_score) VALUES (?, ?)`, mapName),
-		playerName, timerInt,
+		fmt.Sprintf(`INSERT INTO "%s" (player_name, time_score, created_at) VALUES (?, ?, ?)`, mapName),
+		playerName, timerInt, time.Now().Unix(),
 	)
 	if err != nil {
 		log.Printf("[DISCORD] Failed to insert new run for %s (%d) in %s: %v", playerName, timerInt, mapName, err)
@@ -23,6 +24,10 @@ func AddRunToTable(db *sql.DB, mapName, playerName string, timerInt int, allowed
 		return errors.New("failed to insert new run")
 	}
 
+	if err := UpdateWRHistory(db, mapName, allowedMaps); err != nil {
+		log.Printf("[DISCORD] Failed to update WR history for %s: %v", mapName, err)
+	}
+
 	return nil
 }
 
@@ -38,6 +43,8 @@ func RemoveRunFromTable(db *sql.DB, mapName, playerName string, timerInt int, al
 		return errors.New("failed to delete run")
 	}
 
+	RebuildWRHistories(db, mapName, allowedMaps)
+
 	return nil
 }
 
@@ -64,6 +71,8 @@ func DeleteRunsFromTables(db *sql.DB, mapName, playerName string, allowedMaps []
 		}
 	}
 
+	RebuildWRHistories(db, mapName, allowedMaps)
+
 	return nil
 }
 
@@ -82,5 +91,7 @@ func RenameRunsFromTables(db *sql.DB, playerName, newPlayerName string, allowedM
 		}
 	}
 
+	RebuildWRHistories(db, "all", allowedMaps)
+
 	return nil
 }
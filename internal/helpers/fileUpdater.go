@@ -1,192 +1,173 @@
 package helpers
 
 import (
+	"bytes"
 	"database/sql"
+	_ "embed"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
 )
 
-func UpdateTop10File(filepath string, db *sql.DB, allowedMaps []config.MapInfo) {
-
-	top3 := retrieveTop3(db, allowedMaps)
-	top10 := retrieveTop10(db, allowedMaps)
-
-	top1names := ``
-	top2names := ``
-	top3names := ``
-	top10names := ``
-	ingameLB := ``
-	for name, maap := range top3 {
-		for _, player := range maap {
-			switch name {
-			case "firstmap":
-				switch player.Rank {
-				case 1:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#1\", < 0, -879, 40643.5 >, < 0, -90, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-
-				case 2:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#2\", < 0, -879, 40593.5 >, < 0, -90, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 3:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#3\", < 0, -879, 40543.5 >, < 0, -90, 0 >, false, 1 )\n\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				}
-			case "gymmap":
-				switch player.Rank {
-				case 1:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#1\", < 879, 0, 40643.5 >, < 0, 0, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 2:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#2\", < 879, 0, 40593.5 >, < 0, 0, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 3:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#3\", < 879, 0, 40543.5 >, < 0, 0, 0 >, false, 1 )\n\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				}
-			case "ithurtsmap":
-				switch player.Rank {
-				case 1:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#1\", < 0, 879, 40643.5 >, < 0, 90, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 2:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#2\", < 0, 879, 40593.5 >, < 0, 90, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 3:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#3\", < 0, 879, 40543.5 >, < 0, 90, 0 >, false, 1 )\n\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				}
-			case "strafeitmap":
-				switch player.Rank {
-				case 1:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#1\", < -879, 0, 40643.5 >, < 0, -180, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 2:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#2\", < -879, 0, 40593.5 >, < 0, -180, 0 >, false, 1 )\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				case 3:
-					ingameLB += fmt.Sprintf("CreatePanelText(player, \"%s - %s\", \"#3\", < -879, 0, 40543.5 >, < 0, -180, 0 >, false, 1 )\n\n", player.PlayerName, ConvertSecondsToTimer(player.BestTime))
-				}
-			}
-		}
-	}
+//go:embed templates/top10.nut.tmpl
+var defaultTop10Template string
 
-	for _, maap := range top3 {
-		for _, player := range maap {
-			switch player.Rank {
-			case 1:
-				top1names += fmt.Sprintf(`"%s", `, player.PlayerName)
-			case 2:
-				top2names += fmt.Sprintf(`"%s", `, player.PlayerName)
-			case 3:
-				top3names += fmt.Sprintf(`"%s", `, player.PlayerName)
-			}
-		}
-	}
-	for _, maap := range top10 {
-		for _, player := range maap {
-			top10names += fmt.Sprintf(`"%s", `, player.PlayerName)
-		}
-	}
+type top10FileData struct {
+	Top1  []string
+	Top2  []string
+	Top3  []string
+	Top10 []string // Positions 4 to 10
+	Maps  []top10FileMap
+}
 
-	if len(top1names) > 0 {
-		top1names = top1names[:len(top1names)-2]
-	}
-	if len(top2names) > 0 {
-		top2names = top2names[:len(top2names)-2]
-	}
-	if len(top3names) > 0 {
-		top3names = top3names[:len(top3names)-2]
-	}
-	if len(top10names) > 0 {
-		top10names = top10names[:len(top10names)-2]
-	}
+type top10FileMap struct {
+	Name   string
+	Panels []top10FilePanel
+}
 
-	lines := "untyped\n\n"
-	lines += "globalize_all_functions\n\n"
-	lines += fmt.Sprintf("global const array <string> top1_players = [%s]\n", top1names)
-	lines += fmt.Sprintf("global const array <string> top2_players = [%s]\n", top2names)
-	lines += fmt.Sprintf("global const array <string> top3_players = [%s]\n", top3names)
-	lines += fmt.Sprintf("global const array <string> top10_players = [%s]\n\n", top10names)
-	lines += "void function MH_Spawn_Leaderboards(entity player) {\n"
-	lines += ingameLB + "}\n"
+type top10FilePanel struct {
+	Rank       int
+	PlayerName string
+	Time       string
+	Origin     config.Vector
+	Angles     config.Vector
+}
 
-	os.WriteFile(filepath, []byte(lines), 0777)
+var top10TemplateFuncs = template.FuncMap{
+	"quote": squirrelString,
+	"vec": func(v config.Vector) string {
+		return fmt.Sprintf("< %s, %s, %s >", formatCoordinate(v[0]), formatCoordinate(v[1]), formatCoordinate(v[2]))
+	},
+	"names": func(names []string) string {
+		quoted := make([]string, 0, len(names))
+		for _, name := range names {
+			quoted = append(quoted, squirrelString(name))
+		}
+		return strings.Join(quoted, ", ")
+	},
 }
 
 /*
-Returns the 1-3 positions
+Parses the template used to generate the top 10 file.
+An empty path returns the built-in template.
 */
-func retrieveTop3(db *sql.DB, allowedMaps []config.MapInfo) map[string][]LeaderboardEntry {
-	top3Players := make(map[string][]LeaderboardEntry)
-	for _, mapInfo := range allowedMaps {
-		query := fmt.Sprintf(`
-		SELECT player_name, MIN(time_score) as best_time
-		FROM
-		(
-			SELECT MAX(id) as id, player_name, time_score
-			FROM "%s"
-			GROUP BY player_name, time_score
-		)
-		GROUP BY player_name
-		ORDER BY best_time ASC, id DESC
-		LIMIT 3;
-		`, mapInfo.MapName)
-		rows, err := db.Query(query)
+func LoadTop10Template(path string) (*template.Template, error) {
+	content := defaultTop10Template
+	if path != "" {
+		raw, err := os.ReadFile(path)
 		if err != nil {
-			log.Printf("[DISCORD] Failed to execute query while retrieving top 3: %v", err)
-			continue
+			return nil, fmt.Errorf("failed to read top 10 template: %w", err)
 		}
+		content = string(raw)
+	}
 
-		var players []LeaderboardEntry
-		rank := 1
-		for rows.Next() {
-			var entry LeaderboardEntry
-			if err := rows.Scan(&entry.PlayerName, &entry.BestTime); err != nil {
-				log.Printf("[DISCORD] Failed to scan player name: %v", err)
-				continue
-			}
-			entry.Rank = rank
-			players = append(players, entry)
-			rank++
-		}
-		rows.Close()
-		top3Players[mapInfo.MapName] = players
+	tmpl, err := template.New("top10").Funcs(top10TemplateFuncs).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse top 10 template: %w", err)
 	}
-	return top3Players
+	return tmpl, nil
 }
 
 /*
-Returns the 4-10 positions
+Renders the top 10 file with the current leaderboards.
+Maps follow the allowed maps order and players their rank, so the output only changes with the leaderboards.
 */
-func retrieveTop10(db *sql.DB, allowedMaps []config.MapInfo) map[string][]LeaderboardEntry {
-	top10Players := make(map[string][]LeaderboardEntry)
+func RenderTop10File(tmpl *template.Template, db *sql.DB, allowedMaps []config.MapInfo) ([]byte, error) {
+	var data top10FileData
 	for _, mapInfo := range allowedMaps {
-		query := fmt.Sprintf(`
-		SELECT player_name, MIN(time_score) as best_time
-		FROM
-		(
-			SELECT MAX(id) as id, player_name, time_score
-			FROM "%s"
-			GROUP BY player_name, time_score
-		)
-		GROUP BY player_name
-		ORDER BY best_time ASC, id DESC
-		LIMIT 7 OFFSET 3;
-		`, mapInfo.MapName)
-		rows, err := db.Query(query)
-		if err != nil {
-			log.Printf("[HELPER] Failed to execute query while retrieving top 3: %v", err)
-			continue
-		}
+		entries := LeaderboardRangeReader(db, mapInfo.MapName, 1, 10, allowedMaps)
 
-		var players []LeaderboardEntry
-		rank := 4
+		fileMap := top10FileMap{Name: mapInfo.MapName}
+		for _, player := range entries {
+			switch {
+			case player.Rank == 1:
+				data.Top1 = append(data.Top1, player.PlayerName)
+			case player.Rank == 2:
+				data.Top2 = append(data.Top2, player.PlayerName)
+			case player.Rank == 3:
+				data.Top3 = append(data.Top3, player.PlayerName)
+			default:
+				data.Top10 = append(data.Top10, player.PlayerName)
+			}
 
-		for rows.Next() {
-			var entry LeaderboardEntry
-			if err := rows.Scan(&entry.PlayerName, &entry.BestTime); err != nil {
-				log.Printf("[HELPER] Failed to scan player name: %v", err)
+			panel := mapInfo.Panel
+			if panel == nil || player.Rank > panel.Ranks {
 				continue
 			}
-			entry.Rank = rank
-			players = append(players, entry)
-			rank++
+			offset := float64(player.Rank - 1)
+			fileMap.Panels = append(fileMap.Panels, top10FilePanel{
+				Rank:       player.Rank,
+				PlayerName: player.PlayerName,
+				Time:       ConvertSecondsToTimer(player.BestTime),
+				Origin: config.Vector{
+					panel.Origin[0] + offset*panel.Spacing[0],
+					panel.Origin[1] + offset*panel.Spacing[1],
+					panel.Origin[2] + offset*panel.Spacing[2],
+				},
+				Angles: panel.Angles,
+			})
+		}
+		if len(fileMap.Panels) > 0 {
+			data.Maps = append(data.Maps, fileMap)
 		}
-		rows.Close()
-		top10Players[mapInfo.MapName] = players
 	}
-	return top10Players
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render top 10 file: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+/*
+Replaces the file content through a temporary file in the same folder and a rename,
+so readers never see a partially written file.
+*/
+func WriteFileAtomic(path string, content []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Does nothing once the rename succeeded.
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to flush temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	return nil
+}
+
+/*
+Quotes a value as a Squirrel string literal.
+*/
+func squirrelString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
 }
@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files")
+
+type seedRun struct {
+	player string
+	time   int
+}
+
+func TestRenderTop10FileGolden(t *testing.T) {
+	content, err := os.ReadFile("../../map_panels.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	panels := make(map[string]config.PanelLayout)
+	if err := json.Unmarshal(content, &panels); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := map[string][]seedRun{
+		"firstmap": {
+			{"Alpha", 95},
+			{"Bravo", 102},
+			{"Alpha", 90},
+			// Quotes and backslashes must be escaped in the Squirrel strings.
+			{`Dr. "Quote" \Slash`, 99},
+			{"Charlie", 130},
+		},
+		// No runs, the map gets no panel.
+		"gymmap": nil,
+		"ithurtsmap": {
+			{"Delta", 60},
+			{"Echo", 61},
+			{"Foxtrot", 62},
+			{"Golf", 63},
+			{"Hotel", 64},
+		},
+		// No panel, its players are only listed in the arrays.
+		"extramap": {
+			{"India", 300},
+		},
+	}
+
+	var allowedMaps []config.MapInfo
+	for _, mapName := range []string{"firstmap", "gymmap", "ithurtsmap", "extramap"} {
+		mapInfo := config.MapInfo{MapName: mapName}
+		if panel, ok := panels[mapName]; ok {
+			mapInfo.Panel = &panel
+		}
+		allowedMaps = append(allowedMaps, mapInfo)
+	}
+
+	db := seedRunsDB(t, allowedMaps, runs)
+	tmpl, err := LoadTop10Template("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RenderTop10File(tmpl, db, allowedMaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "top10.nut.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("top 10 file differs from %s, run go test -update to accept it\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestSquirrelString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Alpha", `"Alpha"`},
+		{`Dr. "Quote"`, `"Dr. \"Quote\""`},
+		{`back\slash`, `"back\\slash"`},
+		{`\"`, `"\\\""`},
+	}
+	for _, tt := range tests {
+		if got := squirrelString(tt.value); got != tt.want {
+			t.Errorf("squirrelString(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func seedRunsDB(t *testing.T, allowedMaps []config.MapInfo, runs map[string][]seedRun) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: opens its own database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, mapInfo := range allowedMaps {
+		_, err := db.Exec(fmt.Sprintf(`CREATE TABLE "%s" (id INTEGER PRIMARY KEY AUTOINCREMENT, player_name TEXT, time_score INTEGER)`, mapInfo.MapName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, run := range runs[mapInfo.MapName] {
+			_, err := db.Exec(fmt.Sprintf(`INSERT INTO "%s" (player_name, time_score) VALUES (?, ?)`, mapInfo.MapName), run.player, run.time)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return db
+}
@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+Returns up to limit candidates that loosely match the query, best matches first.
+Matching is case insensitive and ranks exact, prefix, substring and subsequence
+matches before names that are only a few typos away.
+*/
+func FuzzyMatch(query string, candidates []string, limit int) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	type scored struct {
+		name  string
+		score int
+	}
+
+	var matches []scored
+	for _, candidate := range candidates {
+		if query == "" {
+			matches = append(matches, scored{name: candidate})
+			continue
+		}
+		if score, ok := fuzzyScore(query, strings.ToLower(candidate)); ok {
+			matches = append(matches, scored{name: candidate, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return len(matches[i].name) < len(matches[j].name)
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match.name)
+	}
+	return names
+}
+
+/*
+Scores how well the candidate matches the query, lower is better.
+Both strings are expected to be lower cased already.
+*/
+func fuzzyScore(query, candidate string) (int, bool) {
+	switch {
+	case candidate == query:
+		return 0, true
+	case strings.HasPrefix(candidate, query):
+		return 1, true
+	case strings.Contains(candidate, query):
+		return 2, true
+	}
+
+	// Subsequence match, e.g. "lrd" for "leonardo", penalized by the skipped characters.
+	queryRunes := []rune(query)
+	matched, gaps := 0, 0
+	for _, r := range candidate {
+		if matched < len(queryRunes) && r == queryRunes[matched] {
+			matched++
+		} else if matched > 0 && matched < len(queryRunes) {
+			gaps++
+		}
+	}
+	if matched == len(queryRunes) {
+		return 3 + gaps, true
+	}
+
+	// Typo tolerance: compare against the candidate prefix of the same size.
+	candidateRunes := []rune(candidate)
+	if len(candidateRunes) > len(queryRunes) {
+		candidateRunes = candidateRunes[:len(queryRunes)]
+	}
+	// Short queries already match plenty of names, so typos are only tolerated from 3 characters on.
+	distance := editDistance(queryRunes, candidateRunes)
+	if maxDistance := len(queryRunes) / 3; distance <= maxDistance {
+		return 100 + distance, true
+	}
+
+	return 0, false
+}
+
+/*
+Returns the optimal string alignment distance, a Levenshtein distance that
+also counts swapped neighbouring characters as a single typo.
+*/
+func editDistance(a, b []rune) int {
+	distances := make([][]int, len(a)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(b)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			distances[i][j] = min(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				distances[i][j] = min(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return distances[len(a)][len(b)]
+}
@@ -16,25 +16,32 @@ type LeaderboardEntry struct {
 	BestTime   int
 }
 
+/*
+Returns the top 10 of a map.
+*/
 func LeaderboardReader(db *sql.DB, mapName string, allowedMaps []config.MapInfo) []LeaderboardEntry {
+	return LeaderboardRangeReader(db, mapName, 1, 10, allowedMaps)
+}
+
+/*
+Returns the leaderboard positions firstRank to lastRank of a map (both included), best first.
+*/
+func LeaderboardRangeReader(db *sql.DB, mapName string, firstRank, lastRank int, allowedMaps []config.MapInfo) []LeaderboardEntry {
 	if !IsValidTable(mapName, allowedMaps) {
 		log.Printf("[DISCORD] Attempted to query an invalid table name: %s", mapName)
 		return nil
 	}
+	if firstRank < 1 || lastRank < firstRank {
+		log.Printf("[DISCORD] Attempted to query an invalid rank range %d-%d on %s", firstRank, lastRank, mapName)
+		return nil
+	}
 
-	query := fmt.Sprintf(`
-		SELECT player_name, MIN(time_score) as best_time
-		FROM
-		(
-			SELECT MAX(id) as id, player_name, time_score
-			FROM "%s"
-			GROUP BY player_name, time_score
-		)
-		GROUP BY player_name
-		ORDER BY best_time ASC, id DESC
-		LIMIT 10;
-		`, mapName)
-	rows, err := db.Query(query)
+	query := rankedRunsQuery([]string{mapName}) + `
+		SELECT player_rank, player_name, best_time
+		FROM ranked
+		WHERE player_rank BETWEEN ? AND ?
+		ORDER BY player_rank`
+	rows, err := db.Query(query, firstRank, lastRank)
 	if err != nil {
 		log.Printf("[DISCORD] Failed to execute query while retrieving Leaderboard: %v", err)
 		return nil
@@ -42,20 +49,53 @@ func LeaderboardReader(db *sql.DB, mapName string, allowedMaps []config.MapInfo)
 	defer rows.Close()
 
 	var entries []LeaderboardEntry
-	rank := 1
 	for rows.Next() {
 		var entry LeaderboardEntry
-		if err := rows.Scan(&entry.PlayerName, &entry.BestTime); err != nil {
+		if err := rows.Scan(&entry.Rank, &entry.PlayerName, &entry.BestTime); err != nil {
 			log.Printf("[DISCORD] Failed to scan row while retrieving Leaderboard: %v", err)
 			continue
 		}
-		entry.Rank = rank
 		entries = append(entries, entry)
-		rank++
 	}
 	return entries
 }
 
+/*
+Returns the start of a query ranking the players of each map, every ranked query builds on it.
+It defines the runs of the maps (map_name, id, player_name, time_score and the extra columns)
+and ranked, the personal best of every player (map_name, player_name, best_time, best_amount, best_id, player_rank).
+Players are ranked by best time, ties go to whoever set it last. Callers add CTEs after a comma or the final SELECT.
+The map names must have been checked with IsValidTable.
+*/
+func rankedRunsQuery(mapNames []string, extraColumns ...string) string {
+	columns := strings.Join(append([]string{"id", "player_name", "time_score"}, extraColumns...), ", ")
+	var selects []string
+	for _, mapName := range mapNames {
+		selects = append(selects, fmt.Sprintf(`SELECT '%s' AS map_name, %s FROM "%s"`,
+			strings.ReplaceAll(mapName, "'", "''"), columns, mapName))
+	}
+
+	return fmt.Sprintf(`
+		WITH runs AS (
+			%s
+		),
+		bests AS (
+			SELECT map_name, player_name, MIN(time_score) AS best_time
+			FROM runs
+			GROUP BY map_name, player_name
+		),
+		personal_bests AS (
+			SELECT runs.map_name, runs.player_name, bests.best_time, COUNT(runs.id) AS best_amount, MAX(runs.id) AS best_id
+			FROM runs
+			JOIN bests ON bests.map_name = runs.map_name AND bests.player_name = runs.player_name AND bests.best_time = runs.time_score
+			GROUP BY runs.map_name, runs.player_name
+		),
+		ranked AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY map_name ORDER BY best_time ASC, best_id DESC) AS player_rank
+			FROM personal_bests
+		)`, strings.Join(selects, "\n\t\t\tUNION ALL\n\t\t\t"))
+}
+
 func TableConstructor(tableName string, entries []LeaderboardEntry) string {
 	if entries == nil {
 		return ""
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// How a channel is listed for link fixing.
+const (
+	LinkFixChannelAllow = "allow"
+	LinkFixChannelDeny  = "deny"
+)
+
+/*
+Turns link fixing off or back on for the replies to a user's messages.
+*/
+func SetLinkFixOptOut(db *sql.DB, userID string, optOut bool) error {
+	var err error
+	if optOut {
+		_, err = db.Exec(`INSERT OR IGNORE INTO linkfix_optouts (user_id, created_at) VALUES (?, ?)`, userID, time.Now().Unix())
+	} else {
+		_, err = db.Exec(`DELETE FROM linkfix_optouts WHERE user_id = ?`, userID)
+	}
+	if err != nil {
+		log.Printf("[DISCORD] Failed to update link fixing opt-out of %s: %v", userID, err)
+		return errors.New("failed to update link fixing opt-out")
+	}
+	return nil
+}
+
+/*
+Returns the IDs of the users who turned link fixing off.
+*/
+func LinkFixOptOutsReader(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT user_id FROM linkfix_optouts`)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve link fixing opt-outs: %v", err)
+		return nil, errors.New("failed to retrieve link fixing opt-outs")
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("[DISCORD] Failed to scan link fixing opt-out: %v", err)
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving link fixing opt-outs: %v", err)
+		return nil, errors.New("failed to retrieve link fixing opt-outs")
+	}
+	return userIDs, nil
+}
+
+/*
+Puts a channel on the allow or deny list, an empty mode takes it off both.
+*/
+func SetLinkFixChannel(db *sql.DB, channelID, mode, setBy string) error {
+	var err error
+	if mode == "" {
+		_, err = db.Exec(`DELETE FROM linkfix_channels WHERE channel_id = ?`, channelID)
+	} else {
+		_, err = db.Exec(`INSERT INTO linkfix_channels (channel_id, mode, set_by, created_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(channel_id) DO UPDATE SET mode = excluded.mode, set_by = excluded.set_by, created_at = excluded.created_at`,
+			channelID, mode, setBy, time.Now().Unix())
+	}
+	if err != nil {
+		log.Printf("[DISCORD] Failed to update link fixing of channel %s: %v", channelID, err)
+		return errors.New("failed to update link fixing channel")
+	}
+	return nil
+}
+
+/*
+Returns the mode of every listed channel, by channel ID.
+*/
+func LinkFixChannelsReader(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query(`SELECT channel_id, mode FROM linkfix_channels`)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve link fixing channels: %v", err)
+		return nil, errors.New("failed to retrieve link fixing channels")
+	}
+	defer rows.Close()
+
+	channels := make(map[string]string)
+	for rows.Next() {
+		var channelID, mode string
+		if err := rows.Scan(&channelID, &mode); err != nil {
+			log.Printf("[DISCORD] Failed to scan link fixing channel: %v", err)
+			continue
+		}
+		channels[channelID] = mode
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving link fixing channels: %v", err)
+		return nil, errors.New("failed to retrieve link fixing channels")
+	}
+	return channels, nil
+}
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/linkfix"
+)
+
+var ErrProviderExists = errors.New("a provider with this name already exists")
+
+/*
+Fills the providers table with the default providers, once.
+Returns false when the providers were already seeded, removing every provider doesn't bring the defaults back.
+*/
+func SeedLinkProviders(db *sql.DB, providers []linkfix.Provider) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var seeded bool
+	if err := tx.QueryRow(`SELECT link_providers_seeded FROM bot_settings WHERE id = 1`).Scan(&seeded); err != nil {
+		return false, err
+	}
+	if seeded {
+		return false, nil
+	}
+
+	for _, provider := range providers {
+		_, err := tx.Exec(`INSERT INTO link_providers (name, pattern, replacement, capture_group, enabled) VALUES (?, ?, ?, ?, ?)`,
+			provider.Name, provider.Pattern, provider.Replacement, provider.CaptureGroup, provider.Enabled)
+		if err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE bot_settings SET link_providers_seeded = 1 WHERE id = 1`); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+/*
+Stores a new provider, failing with ErrProviderExists when the name is taken.
+*/
+func AddLinkProvider(db *sql.DB, provider linkfix.Provider) error {
+	_, err := db.Exec(`INSERT INTO link_providers (name, pattern, replacement, capture_group, enabled) VALUES (?, ?, ?, ?, ?)`,
+		provider.Name, provider.Pattern, provider.Replacement, provider.CaptureGroup, provider.Enabled)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrProviderExists
+		}
+		log.Printf("[DISCORD] Failed to add link provider %s: %v", provider.Name, err)
+		return errors.New("failed to add link provider")
+	}
+	return nil
+}
+
+/*
+Removes a provider by its name, returning false when there was none.
+*/
+func RemoveLinkProvider(db *sql.DB, name string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM link_providers WHERE name = ?`, name)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to remove link provider %s: %v", name, err)
+		return false, errors.New("failed to remove link provider")
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+/*
+Turns a provider on or off by its name, returning false when there is none.
+*/
+func SetLinkProviderEnabled(db *sql.DB, name string, enabled bool) (bool, error) {
+	result, err := db.Exec(`UPDATE link_providers SET enabled = ? WHERE name = ?`, enabled, name)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to update link provider %s: %v", name, err)
+		return false, errors.New("failed to update link provider")
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+/*
+Returns every stored provider in the order they were added.
+*/
+func LinkProvidersReader(db *sql.DB) ([]linkfix.Provider, error) {
+	rows, err := db.Query(`SELECT name, pattern, replacement, capture_group, enabled FROM link_providers ORDER BY id`)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve link providers: %v", err)
+		return nil, errors.New("failed to retrieve link providers")
+	}
+	defer rows.Close()
+
+	var providers []linkfix.Provider
+	for rows.Next() {
+		var provider linkfix.Provider
+		if err := rows.Scan(&provider.Name, &provider.Pattern, &provider.Replacement, &provider.CaptureGroup, &provider.Enabled); err != nil {
+			log.Printf("[DISCORD] Failed to scan link provider: %v", err)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving link providers: %v", err)
+		return nil, errors.New("failed to retrieve link providers")
+	}
+	return providers, nil
+}
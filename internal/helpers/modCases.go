@@ -0,0 +1,192 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+/*
+A moderation action taken against a user, automatically or by a moderator.
+*/
+type ModCase struct {
+	ID          int64
+	UserID      string
+	Username    string
+	Action      string        // ban, kick, timeout, delete or unban
+	Duration    time.Duration // Only set for timeouts
+	ModeratorID string        // Empty when the bot acted on its own
+	Rule        string        // The moderation rule that was broken, if any
+	Reason      string
+	ChannelID   string
+	Content     string   // The offending message
+	Attachments []string // URLs of the offending message attachments
+	Error       string   // Set when the action failed
+	CreatedAt   time.Time
+}
+
+const modCaseColumns = `id, user_id, username, action, duration, moderator_id, rule, reason, channel_id, content, attachments, error, created_at`
+
+/*
+Stores a new case and returns its number.
+*/
+func CreateModCase(db *sql.DB, modCase *ModCase) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO mod_cases (user_id, username, action, duration, moderator_id, rule, reason, channel_id, content, attachments, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		modCase.UserID, modCase.Username, modCase.Action, int64(modCase.Duration.Seconds()), modCase.ModeratorID, modCase.Rule,
+		modCase.Reason, modCase.ChannelID, modCase.Content, strings.Join(modCase.Attachments, "\n"), modCase.Error, modCase.CreatedAt.Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to store %s case of %s: %v", modCase.Action, modCase.UserID, err)
+		return 0, errors.New("failed to store moderation case")
+	}
+	return result.LastInsertId()
+}
+
+/*
+Returns a case by its number, nil when there is none.
+*/
+func ModCaseReader(db *sql.DB, id int64) *ModCase {
+	rows, err := db.Query(`SELECT `+modCaseColumns+` FROM mod_cases WHERE id = ?`, id)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve case %d: %v", id, err)
+		return nil
+	}
+	defer rows.Close()
+
+	cases := scanModCases(rows)
+	if len(cases) == 0 {
+		return nil
+	}
+	return &cases[0]
+}
+
+/*
+Returns the latest cases of a user, newest first, and how many they have in total.
+*/
+func UserModCasesReader(db *sql.DB, userID string, limit int) ([]ModCase, int) {
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM mod_cases WHERE user_id = ?`, userID).Scan(&total); err != nil {
+		log.Printf("[DISCORD] Failed to count cases of %s: %v", userID, err)
+		return nil, 0
+	}
+
+	rows, err := db.Query(`SELECT `+modCaseColumns+` FROM mod_cases WHERE user_id = ? ORDER BY id DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve cases of %s: %v", userID, err)
+		return nil, 0
+	}
+	defer rows.Close()
+
+	return scanModCases(rows), total
+}
+
+func scanModCases(rows *sql.Rows) []ModCase {
+	var cases []ModCase
+	for rows.Next() {
+		var (
+			modCase     ModCase
+			duration    int64
+			attachments string
+			createdAt   int64
+		)
+		err := rows.Scan(&modCase.ID, &modCase.UserID, &modCase.Username, &modCase.Action, &duration, &modCase.ModeratorID, &modCase.Rule,
+			&modCase.Reason, &modCase.ChannelID, &modCase.Content, &attachments, &modCase.Error, &createdAt)
+		if err != nil {
+			log.Printf("[DISCORD] Failed to scan moderation case: %v", err)
+			continue
+		}
+		modCase.Duration = time.Duration(duration) * time.Second
+		if attachments != "" {
+			modCase.Attachments = strings.Split(attachments, "\n")
+		}
+		modCase.CreatedAt = time.Unix(createdAt, 0)
+		cases = append(cases, modCase)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving moderation cases: %v", err)
+	}
+	return cases
+}
+
+// Events of a case history.
+const (
+	CaseEventNotified     = "notified"      // The user got a DM about the case
+	CaseEventNotifyFailed = "notify failed" // The DM couldn't be delivered, usually because the user blocks DMs
+	CaseEventAppealed     = "appealed"
+	CaseEventUnbanned     = "unbanned"  // The appeal was accepted
+	CaseEventKept         = "kept"      // The appeal was rejected
+	CaseEventRetracted    = "retracted" // The ban failed and the user was told it wasn't applied
+)
+
+type CaseEvent struct {
+	ID        int64
+	CaseID    int64
+	Event     string
+	ActorID   string // Who caused the event, empty for the bot
+	Note      string
+	CreatedAt time.Time
+}
+
+/*
+Records the error of an action that failed after its case was opened.
+*/
+func SetModCaseError(db *sql.DB, id int64, actionErr string) error {
+	_, err := db.Exec(`UPDATE mod_cases SET error = ? WHERE id = ?`, actionErr, id)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to record the error of case %d: %v", id, err)
+		return errors.New("failed to update moderation case")
+	}
+	return nil
+}
+
+/*
+Appends an event to the history of a case.
+*/
+func AddCaseEvent(db *sql.DB, caseID int64, event, actorID, note string) error {
+	_, err := db.Exec(`INSERT INTO case_events (case_id, event, actor_id, note, created_at) VALUES (?, ?, ?, ?, ?)`,
+		caseID, event, actorID, note, time.Now().Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to record %s event of case %d: %v", event, caseID, err)
+		return errors.New("failed to record case event")
+	}
+	return nil
+}
+
+/*
+Returns the history of a case, oldest first.
+*/
+func CaseEventsReader(db *sql.DB, caseID int64) []CaseEvent {
+	rows, err := db.Query(`
+		SELECT id, case_id, event, actor_id, note, created_at
+		FROM case_events
+		WHERE case_id = ?
+		ORDER BY id ASC`, caseID)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve the history of case %d: %v", caseID, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var events []CaseEvent
+	for rows.Next() {
+		var (
+			event     CaseEvent
+			createdAt int64
+		)
+		if err := rows.Scan(&event.ID, &event.CaseID, &event.Event, &event.ActorID, &event.Note, &createdAt); err != nil {
+			log.Printf("[DISCORD] Failed to scan case event: %v", err)
+			continue
+		}
+		event.CreatedAt = time.Unix(createdAt, 0)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving case events: %v", err)
+	}
+	return events
+}
@@ -0,0 +1,125 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/moderation"
+)
+
+var ErrRuleExists = errors.New("a rule with this name already exists")
+
+type StoredRule struct {
+	moderation.Rule
+	ID        int64
+	CreatedBy string // Discord ID of the admin who added it, empty for rules seeded from the configuration
+	CreatedAt time.Time
+}
+
+/*
+Fills the rules table with the rules from the configuration, once.
+Returns false when the rules were already seeded, the database is the source of truth from then on
+and removing every rule doesn't bring the configured ones back.
+*/
+func SeedModRules(db *sql.DB, rules []moderation.Rule) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var seeded bool
+	if err := tx.QueryRow(`SELECT mod_rules_seeded FROM bot_settings WHERE id = 1`).Scan(&seeded); err != nil {
+		return false, err
+	}
+	if seeded {
+		return false, nil
+	}
+
+	now := time.Now().Unix()
+	for _, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = rule.Pattern
+		}
+		if rule.Match == "" {
+			rule.Match = moderation.MatchContains
+		}
+		// INSERT OR IGNORE as two legacy words may only differ by case.
+		_, err := tx.Exec(`INSERT OR IGNORE INTO mod_rules (name, match, pattern, action, timeout, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			rule.Name, string(rule.Match), rule.Pattern, rule.Action, rule.Timeout, now)
+		if err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE bot_settings SET mod_rules_seeded = 1 WHERE id = 1`); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+/*
+Stores a new rule, failing with ErrRuleExists when the name is taken.
+*/
+func AddModRule(db *sql.DB, rule moderation.Rule, createdBy string) error {
+	_, err := db.Exec(`INSERT INTO mod_rules (name, match, pattern, action, timeout, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rule.Name, string(rule.Match), rule.Pattern, rule.Action, rule.Timeout, createdBy, time.Now().Unix())
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrRuleExists
+		}
+		log.Printf("[DISCORD] Failed to add moderation rule %s: %v", rule.Name, err)
+		return errors.New("failed to add moderation rule")
+	}
+	return nil
+}
+
+/*
+Removes a rule by its name, returning false when there was none.
+*/
+func RemoveModRule(db *sql.DB, name string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM mod_rules WHERE name = ?`, name)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to remove moderation rule %s: %v", name, err)
+		return false, errors.New("failed to remove moderation rule")
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+/*
+Returns every stored rule in the order they were added.
+*/
+func ModRulesReader(db *sql.DB) ([]StoredRule, error) {
+	rows, err := db.Query(`SELECT id, name, match, pattern, action, timeout, created_by, created_at FROM mod_rules ORDER BY id`)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve moderation rules: %v", err)
+		return nil, errors.New("failed to retrieve moderation rules")
+	}
+	defer rows.Close()
+
+	var rules []StoredRule
+	for rows.Next() {
+		var (
+			rule      StoredRule
+			match     string
+			createdAt int64
+		)
+		err := rows.Scan(&rule.ID, &rule.Name, &match, &rule.Pattern, &rule.Action, &rule.Timeout, &rule.CreatedBy, &createdAt)
+		if err != nil {
+			log.Printf("[DISCORD] Failed to scan moderation rule: %v", err)
+			continue
+		}
+		rule.Match = moderation.MatchKind(match)
+		rule.CreatedAt = time.Unix(createdAt, 0)
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving moderation rules: %v", err)
+		return nil, errors.New("failed to retrieve moderation rules")
+	}
+	return rules, nil
+}
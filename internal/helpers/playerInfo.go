@@ -4,11 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/leonardomlouzas/GoldenSapling/internal/config"
 )
 
 type PlayerInfo struct {
+	Rank           int
 	BestTime       string
 	TotalRuns      int
 	LastRun        string
@@ -18,57 +20,128 @@ type PlayerInfo struct {
 	SlowestRun     string
 }
 
+/*
+Raw statistics of a player on a single map, times are in seconds.
+*/
+type PlayerStats struct {
+	PlayerName     string
+	Rank           int
+	BestTime       int
+	BestTimeAmount int
+	TotalRuns      int
+	TotalTime      int
+	SlowestRun     int
+	FirstRun       int
+	LastRun        int
+}
+
 func PlayerInfoReader(db *sql.DB, playerName string, mapName string, allowedMaps []config.MapInfo) *PlayerInfo {
+	stats := PlayerStatsReader(db, mapName, []string{playerName}, allowedMaps)
+	if stats == nil {
+		return nil
+	}
+
+	entry, ok := stats[playerName]
+	if !ok {
+		return &PlayerInfo{
+			BestTime:   ConvertSecondsToTimer(0),
+			LastRun:    ConvertSecondsToTimer(0),
+			FirstRun:   ConvertSecondsToTimer(0),
+			TotalTime:  ConvertSecondsToTimer(0),
+			SlowestRun: ConvertSecondsToTimer(0),
+		}
+	}
+
+	return &PlayerInfo{
+		Rank:           entry.Rank,
+		BestTime:       ConvertSecondsToTimer(entry.BestTime),
+		TotalRuns:      entry.TotalRuns,
+		LastRun:        ConvertSecondsToTimer(entry.LastRun),
+		FirstRun:       ConvertSecondsToTimer(entry.FirstRun),
+		TotalTime:      ConvertSecondsToTimer(entry.TotalTime),
+		BestTimeAmount: fmt.Sprint(entry.BestTimeAmount),
+		SlowestRun:     ConvertSecondsToTimer(entry.SlowestRun),
+	}
+}
+
+/*
+Returns the statistics of several players on a map using a single query.
+Players without runs on the map are missing from the result, a nil result means the query failed.
+The rank follows the same ordering as the leaderboard.
+*/
+func PlayerStatsReader(db *sql.DB, mapName string, playerNames []string, allowedMaps []config.MapInfo) map[string]*PlayerStats {
 	if !IsValidTable(mapName, allowedMaps) {
 		log.Printf("[DISCORD] Attempted to query an invalid table name: %s", mapName)
 		return nil
 	}
 
-	query := fmt.Sprintf(`
+	result := make(map[string]*PlayerStats, len(playerNames))
+	if len(playerNames) == 0 {
+		return result
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(playerNames)), ", ")
+	query := rankedRunsQuery([]string{mapName}) + fmt.Sprintf(`,
+		stats AS (
+			SELECT
+				player_name,
+				COUNT(time_score) AS total_runs,
+				SUM(time_score) AS total_time,
+				MAX(time_score) AS slowest_time,
+				MIN(id) AS first_id,
+				MAX(id) AS last_id
+			FROM runs
+			GROUP BY player_name
+		)
 		SELECT
-			MIN(time_score),
-			COUNT(time_score),
-			SUM(time_score),
-			MAX(time_score),
-			(SELECT time_score FROM "%s" WHERE player_name = ? ORDER BY id ASC LIMIT 1),
-			(SELECT time_score FROM "%s" WHERE player_name = ? ORDER BY id DESC LIMIT 1)
-		FROM "%s"
-		WHERE player_name = ?`, mapName, mapName, mapName)
+			ranked.player_name,
+			ranked.player_rank,
+			ranked.best_time,
+			ranked.best_amount,
+			stats.total_runs,
+			stats.total_time,
+			stats.slowest_time,
+			(SELECT time_score FROM "%[1]s" WHERE id = stats.first_id),
+			(SELECT time_score FROM "%[1]s" WHERE id = stats.last_id)
+		FROM ranked
+		JOIN stats ON stats.player_name = ranked.player_name
+		WHERE ranked.player_name IN (%[2]s)`, mapName, placeholders)
 
-	var bestTime, totalRuns, totalTime, slowestTime, firstRun, lastRun sql.NullInt64
-	err := db.QueryRow(query, playerName, playerName, playerName).Scan(
-		&bestTime,
-		&totalRuns,
-		&totalTime,
-		&slowestTime,
-		&firstRun,
-		&lastRun,
-	)
+	args := make([]any, 0, len(playerNames))
+	for _, name := range playerNames {
+		args = append(args, name)
+	}
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return &PlayerInfo{}
-		}
-		log.Printf("[DISCORD] Failed to retrieve player info for %s on map %s: %v", playerName, mapName, err)
+		log.Printf("[DISCORD] Failed to retrieve player stats on map %s: %v", mapName, err)
 		return nil
 	}
+	defer rows.Close()
 
-	var bestTimeAmount string
-	if bestTime.Valid {
-		countQuery := fmt.Sprintf(`SELECT COUNT(time_score) FROM "%s" WHERE player_name = ? AND time_score = ?`, mapName)
-		err = db.QueryRow(countQuery, playerName, bestTime.Int64).Scan(&bestTimeAmount)
+	for rows.Next() {
+		var entry PlayerStats
+		err := rows.Scan(
+			&entry.PlayerName,
+			&entry.Rank,
+			&entry.BestTime,
+			&entry.BestTimeAmount,
+			&entry.TotalRuns,
+			&entry.TotalTime,
+			&entry.SlowestRun,
+			&entry.FirstRun,
+			&entry.LastRun,
+		)
 		if err != nil {
-			log.Printf("[DISCORD] Failed to retrieve best time amount for player %s on map %s: %v", playerName, mapName, err)
-			return nil
+			log.Printf("[DISCORD] Failed to scan player stats on map %s: %v", mapName, err)
+			continue
 		}
+		result[entry.PlayerName] = &entry
 	}
 
-	return &PlayerInfo{
-		BestTime:       ConvertSecondsToTimer(int(bestTime.Int64)),
-		TotalRuns:      int(totalRuns.Int64),
-		LastRun:        ConvertSecondsToTimer(int(lastRun.Int64)),
-		FirstRun:       ConvertSecondsToTimer(int(firstRun.Int64)),
-		TotalTime:      ConvertSecondsToTimer(int(totalTime.Int64)),
-		BestTimeAmount: bestTimeAmount,
-		SlowestRun:     ConvertSecondsToTimer(int(slowestTime.Int64)),
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving player stats on map %s: %v", mapName, err)
 	}
+
+	return result
 }
@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+)
+
+type PlayerLink struct {
+	DiscordID  string
+	PlayerName string
+}
+
+/*
+Links a Discord account to an in-game player name, replacing any previous link of the account.
+*/
+func LinkPlayer(db *sql.DB, discordID, playerName string) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO player_links (discord_id, player_name) VALUES (?, ?)`, discordID, playerName)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to link %s to player %s: %v", discordID, playerName, err)
+		return errors.New("failed to link player")
+	}
+	return nil
+}
+
+/*
+Removes the link of a Discord account, returning false when there was none.
+*/
+func UnlinkPlayer(db *sql.DB, discordID string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM player_links WHERE discord_id = ?`, discordID)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to unlink %s: %v", discordID, err)
+		return false, errors.New("failed to unlink player")
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func PlayerLinksReader(db *sql.DB) []PlayerLink {
+	rows, err := db.Query(`SELECT discord_id, player_name FROM player_links ORDER BY discord_id`)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve player links: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var links []PlayerLink
+	for rows.Next() {
+		var link PlayerLink
+		if err := rows.Scan(&link.DiscordID, &link.PlayerName); err != nil {
+			log.Printf("[DISCORD] Failed to scan player link: %v", err)
+			continue
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving player links: %v", err)
+	}
+
+	return links
+}
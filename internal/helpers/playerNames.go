@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+/*
+Returns every distinct player name recorded in the given maps.
+Invalid map names are ignored, an empty list means all allowed maps.
+*/
+func PlayerNamesReader(db *sql.DB, mapNames []string, allowedMaps []config.MapInfo) []string {
+	if len(mapNames) == 0 {
+		for _, maap := range allowedMaps {
+			mapNames = append(mapNames, maap.MapName)
+		}
+	}
+
+	var selects []string
+	for _, mapName := range mapNames {
+		if !IsValidTable(mapName, allowedMaps) {
+			continue
+		}
+		selects = append(selects, fmt.Sprintf(`SELECT player_name FROM "%s"`, mapName))
+	}
+	if len(selects) == 0 {
+		return nil
+	}
+
+	// UNION already removes duplicated names between the tables.
+	query := strings.Join(selects, " UNION ") + " ORDER BY player_name"
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve player names: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Printf("[DISCORD] Failed to scan player name: %v", err)
+			continue
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving player names: %v", err)
+	}
+
+	return names
+}
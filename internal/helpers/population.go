@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// How long player counts are kept, older samples are deleted as new ones come in.
+const PlayerCountRetention = 90 * 24 * time.Hour
+
+type PopulationSample struct {
+	RecordedAt time.Time
+	Players    int
+	MaxPlayers int
+}
+
+/*
+Stores the player count of a watched server and drops samples older than PlayerCountRetention.
+*/
+func RecordPlayerCount(db *sql.DB, serverName string, players, maxPlayers int, recordedAt time.Time) error {
+	_, err := db.Exec(`INSERT INTO player_counts (server_name, recorded_at, players, max_players) VALUES (?, ?, ?, ?)`,
+		serverName, recordedAt.Unix(), players, maxPlayers)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to record player count of %s: %v", serverName, err)
+		return errors.New("failed to record player count")
+	}
+
+	_, err = db.Exec(`DELETE FROM player_counts WHERE server_name = ? AND recorded_at < ?`,
+		serverName, recordedAt.Add(-PlayerCountRetention).Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to delete old player counts of %s: %v", serverName, err)
+	}
+	return nil
+}
+
+/*
+Returns the player counts of a watched server recorded since the given time, oldest first.
+*/
+func PopulationReader(db *sql.DB, serverName string, since time.Time) []PopulationSample {
+	rows, err := db.Query(`
+		SELECT recorded_at, players, max_players
+		FROM player_counts
+		WHERE server_name = ? AND recorded_at >= ?
+		ORDER BY recorded_at ASC`, serverName, since.Unix())
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve player counts of %s: %v", serverName, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var samples []PopulationSample
+	for rows.Next() {
+		var (
+			sample     PopulationSample
+			recordedAt int64
+		)
+		if err := rows.Scan(&recordedAt, &sample.Players, &sample.MaxPlayers); err != nil {
+			log.Printf("[DISCORD] Failed to scan player count: %v", err)
+			continue
+		}
+		sample.RecordedAt = time.Unix(recordedAt, 0)
+		samples = append(samples, sample)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving player counts: %v", err)
+	}
+	return samples
+}
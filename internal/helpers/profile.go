@@ -0,0 +1,144 @@
+package helpers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+/*
+A personal best improvement, RunID orders points in time even when the date is unknown.
+*/
+type ProgressPoint struct {
+	RunID      int
+	BestTime   int
+	RecordedAt time.Time // Zero for runs recorded before dates were stored.
+}
+
+type MapProfile struct {
+	MapName     string
+	Rank        int
+	BestTime    int
+	TotalRuns   int
+	TotalTime   int
+	Progression []ProgressPoint
+}
+
+type PlayerProfile struct {
+	PlayerName string
+	Maps       []MapProfile // Only maps with runs, in the allowed maps order.
+	TotalRuns  int
+	TotalTime  int
+	FirstRunAt time.Time // Zero when no run has a known date.
+	LastRunAt  time.Time
+	RecentRuns int // Runs in the last RecentActivityWindow.
+	Top1       int
+	Top3       int
+	Top10      int
+}
+
+const RecentActivityWindow = 7 * 24 * time.Hour
+
+/*
+Returns the statistics of a player across every allowed map using a single query.
+A nil result means the query failed, a profile without maps means the player has no runs.
+*/
+func PlayerProfileReader(db *sql.DB, playerName string, allowedMaps []config.MapInfo) *PlayerProfile {
+	profile := &PlayerProfile{PlayerName: playerName}
+	if len(allowedMaps) == 0 {
+		return profile
+	}
+
+	mapNames := make([]string, 0, len(allowedMaps))
+	for _, mapInfo := range allowedMaps {
+		mapNames = append(mapNames, mapInfo.MapName)
+	}
+	query := rankedRunsQuery(mapNames, "created_at") + `
+		SELECT runs.map_name, runs.id, runs.time_score, runs.created_at, ranked.player_rank
+		FROM runs
+		JOIN ranked ON ranked.map_name = runs.map_name AND ranked.player_name = runs.player_name
+		WHERE runs.player_name = ?
+		ORDER BY runs.id ASC`
+
+	rows, err := db.Query(query, playerName)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve profile for player %s: %v", playerName, err)
+		return nil
+	}
+	defer rows.Close()
+
+	byMap := make(map[string]*MapProfile)
+	recentSince := time.Now().Add(-RecentActivityWindow)
+	for rows.Next() {
+		var (
+			mapName   string
+			runID     int
+			timeScore int
+			createdAt sql.NullInt64
+			rank      int
+		)
+		if err := rows.Scan(&mapName, &runID, &timeScore, &createdAt, &rank); err != nil {
+			log.Printf("[DISCORD] Failed to scan profile row for player %s: %v", playerName, err)
+			continue
+		}
+
+		mapProfile, ok := byMap[mapName]
+		if !ok {
+			mapProfile = &MapProfile{MapName: mapName, Rank: rank}
+			byMap[mapName] = mapProfile
+		}
+		mapProfile.TotalRuns++
+		mapProfile.TotalTime += timeScore
+
+		var recordedAt time.Time
+		if createdAt.Valid {
+			recordedAt = time.Unix(createdAt.Int64, 0)
+			if profile.FirstRunAt.IsZero() || recordedAt.Before(profile.FirstRunAt) {
+				profile.FirstRunAt = recordedAt
+			}
+			if recordedAt.After(profile.LastRunAt) {
+				profile.LastRunAt = recordedAt
+			}
+			if recordedAt.After(recentSince) {
+				profile.RecentRuns++
+			}
+		}
+
+		if mapProfile.BestTime == 0 || timeScore < mapProfile.BestTime {
+			mapProfile.BestTime = timeScore
+			mapProfile.Progression = append(mapProfile.Progression, ProgressPoint{
+				RunID:      runID,
+				BestTime:   timeScore,
+				RecordedAt: recordedAt,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving profile for player %s: %v", playerName, err)
+	}
+
+	for _, mapInfo := range allowedMaps {
+		mapProfile, ok := byMap[mapInfo.MapName]
+		if !ok {
+			continue
+		}
+
+		profile.Maps = append(profile.Maps, *mapProfile)
+		profile.TotalRuns += mapProfile.TotalRuns
+		profile.TotalTime += mapProfile.TotalTime
+		if mapProfile.Rank == 1 {
+			profile.Top1++
+		}
+		if mapProfile.Rank <= 3 {
+			profile.Top3++
+		}
+		if mapProfile.Rank <= 10 {
+			profile.Top10++
+		}
+	}
+
+	return profile
+}
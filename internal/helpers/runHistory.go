@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+type RunRecord struct {
+	ID         int
+	PlayerName string
+	TimeScore  int
+	RecordedAt time.Time // Zero for runs recorded before dates were stored.
+}
+
+/*
+Returns every run of a player on a map, oldest first.
+*/
+func RunHistoryReader(db *sql.DB, playerName, mapName string, allowedMaps []config.MapInfo) []RunRecord {
+	if !IsValidTable(mapName, allowedMaps) {
+		log.Printf("[DISCORD] Attempted to query an invalid table name: %s", mapName)
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, player_name, time_score, created_at
+		FROM "%s"
+		WHERE player_name = ?
+		ORDER BY id ASC`, mapName)
+
+	rows, err := db.Query(query, playerName)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve run history for player %s on map %s: %v", playerName, mapName, err)
+		return nil
+	}
+	defer rows.Close()
+
+	return scanRunRecords(rows, mapName)
+}
+
+func scanRunRecords(rows *sql.Rows, mapName string) []RunRecord {
+	var records []RunRecord
+	for rows.Next() {
+		var record RunRecord
+		var createdAt sql.NullInt64
+		if err := rows.Scan(&record.ID, &record.PlayerName, &record.TimeScore, &createdAt); err != nil {
+			log.Printf("[DISCORD] Failed to scan run on map %s: %v", mapName, err)
+			continue
+		}
+		if createdAt.Valid {
+			record.RecordedAt = time.Unix(createdAt.Int64, 0)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[DISCORD] Row iteration error while retrieving runs on map %s: %v", mapName, err)
+	}
+
+	return records
+}
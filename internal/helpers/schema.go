@@ -0,0 +1,248 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+/*
+Brings the database schema up to date with what the bot expects.
+Run tables get a nullable created_at column (unix seconds), runs recorded before it existed keep it NULL.
+The game server creates the run tables, the ones it didn't create yet are migrated on a later start.
+*/
+func MigrateDB(db *sql.DB, allowedMaps []config.MapInfo) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS wr_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			map_name TEXT NOT NULL,
+			run_id INTEGER NOT NULL,
+			player_name TEXT NOT NULL,
+			time_score INTEGER NOT NULL,
+			achieved_at INTEGER
+		);
+		CREATE INDEX IF NOT EXISTS wr_history_map_run ON wr_history (map_name, run_id);`)
+	if err != nil {
+		return fmt.Errorf("failed to create wr_history table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS player_links (
+			discord_id TEXT PRIMARY KEY,
+			player_name TEXT NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create player_links table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS player_counts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			server_name TEXT NOT NULL,
+			recorded_at INTEGER NOT NULL,
+			players INTEGER NOT NULL,
+			max_players INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS player_counts_server_time ON player_counts (server_name, recorded_at);`)
+	if err != nil {
+		return fmt.Errorf("failed to create player_counts table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS mod_cases (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			username TEXT NOT NULL,
+			action TEXT NOT NULL,
+			duration INTEGER NOT NULL DEFAULT 0,
+			moderator_id TEXT NOT NULL DEFAULT '',
+			rule TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			channel_id TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL DEFAULT '',
+			attachments TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS mod_cases_user ON mod_cases (user_id, id);`)
+	if err != nil {
+		return fmt.Errorf("failed to create mod_cases table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS case_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			case_id INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			actor_id TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS case_events_case ON case_events (case_id, id);`)
+	if err != nil {
+		return fmt.Errorf("failed to create case_events table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS mod_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			match TEXT NOT NULL,
+			pattern TEXT NOT NULL,
+			action TEXT NOT NULL,
+			timeout TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create mod_rules table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS link_providers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			pattern TEXT NOT NULL,
+			replacement TEXT NOT NULL,
+			capture_group INTEGER NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 1
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create link_providers table: %w", err)
+	}
+
+	// A single row of flags, databases that already had rules or providers count as seeded.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS bot_settings (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			mod_rules_seeded INTEGER NOT NULL DEFAULT 0,
+			link_providers_seeded INTEGER NOT NULL DEFAULT 0
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create bot_settings table: %w", err)
+	}
+	exists, err := columnExists(db, "bot_settings", "link_providers_seeded")
+	if err != nil {
+		return fmt.Errorf("failed to inspect table bot_settings: %w", err)
+	}
+	if !exists {
+		_, err = db.Exec(`
+			ALTER TABLE bot_settings ADD COLUMN link_providers_seeded INTEGER NOT NULL DEFAULT 0;
+			UPDATE bot_settings SET link_providers_seeded = EXISTS (SELECT 1 FROM link_providers);`)
+		if err != nil {
+			return fmt.Errorf("failed to add link_providers_seeded column to bot_settings: %w", err)
+		}
+	}
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO bot_settings (id, mod_rules_seeded, link_providers_seeded)
+		VALUES (1, EXISTS (SELECT 1 FROM mod_rules), EXISTS (SELECT 1 FROM link_providers));`)
+	if err != nil {
+		return fmt.Errorf("failed to fill bot_settings table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS linkfix_optouts (
+			user_id TEXT PRIMARY KEY,
+			created_at INTEGER NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create linkfix_optouts table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS linkfix_channels (
+			channel_id TEXT PRIMARY KEY,
+			mode TEXT NOT NULL,
+			set_by TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create linkfix_channels table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS announcements (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id TEXT NOT NULL,
+			content TEXT NOT NULL,
+			schedule TEXT NOT NULL DEFAULT '',
+			next_run INTEGER NOT NULL,
+			ttl INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create announcements table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS announcement_posts (
+			message_id TEXT PRIMARY KEY,
+			channel_id TEXT NOT NULL,
+			delete_at INTEGER NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement_posts table: %w", err)
+	}
+
+	for _, mapInfo := range allowedMaps {
+		exists, err := tableExists(db, mapInfo.MapName)
+		if err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", mapInfo.MapName, err)
+		}
+		if !exists {
+			log.Printf("[DISCORD] Table %s doesn't exist yet, its created_at column is added on the next start", mapInfo.MapName)
+			continue
+		}
+
+		exists, err = columnExists(db, mapInfo.MapName, "created_at")
+		if err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", mapInfo.MapName, err)
+		}
+		if exists {
+			continue
+		}
+
+		_, err = db.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN created_at INTEGER`, mapInfo.MapName))
+		if err != nil {
+			return fmt.Errorf("failed to add created_at column to %s: %w", mapInfo.MapName, err)
+		}
+	}
+
+	return nil
+}
+
+func tableExists(db *sql.DB, tableName string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ? COLLATE NOCASE`, tableName).Scan(&count)
+	return count > 0, err
+}
+
+func columnExists(db *sql.DB, tableName, columnName string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, tableName))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == columnName {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
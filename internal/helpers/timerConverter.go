@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func ConvertSecondsToTimer(seconds int) string {
@@ -33,3 +34,20 @@ func ConvetTimerToSeconds(timer string) int {
 
 	return minutes*60 + seconds
 }
+
+/*
+Formats a long duration with its two most significant units, e.g. "12d 3h" or "3h 20m".
+*/
+func FormatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+type WRHistoryEntry struct {
+	RunRecord
+	ReignEnd time.Time // Zero while the record still stands or when the next record has no date.
+	Current  bool
+}
+
+/*
+Appends the records set by runs newer than the last stored transition of the map.
+It scans every run since the current record, or the whole table when the map has no record yet.
+*/
+func UpdateWRHistory(db *sql.DB, mapName string, allowedMaps []config.MapInfo) error {
+	if !IsValidTable(mapName, allowedMaps) {
+		return fmt.Errorf("invalid table %s", mapName)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var lastRunID, currentRecord sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT run_id, time_score
+		FROM wr_history
+		WHERE map_name = ?
+		ORDER BY run_id DESC
+		LIMIT 1`, mapName).Scan(&lastRunID, &currentRecord)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT id, player_name, time_score, created_at
+		FROM "%s"
+		WHERE id > ?
+		ORDER BY id ASC`, mapName), lastRunID.Int64)
+	if err != nil {
+		return err
+	}
+	runs := scanRunRecords(rows, mapName)
+	rows.Close()
+
+	for _, run := range runs {
+		if currentRecord.Valid && int64(run.TimeScore) >= currentRecord.Int64 {
+			continue
+		}
+		if err := insertWRHistory(tx, mapName, run); err != nil {
+			return err
+		}
+		currentRecord = sql.NullInt64{Int64: int64(run.TimeScore), Valid: true}
+	}
+
+	return tx.Commit()
+}
+
+/*
+Recomputes the whole record history of the map from its runs.
+Needed whenever existing runs are removed or renamed.
+*/
+func RebuildWRHistory(db *sql.DB, mapName string, allowedMaps []config.MapInfo) error {
+	if !IsValidTable(mapName, allowedMaps) {
+		return fmt.Errorf("invalid table %s", mapName)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM wr_history WHERE map_name = ?`, mapName); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO wr_history (map_name, run_id, player_name, time_score, achieved_at)
+		SELECT ?, id, player_name, time_score, created_at
+		FROM
+		(
+			SELECT
+				id,
+				player_name,
+				time_score,
+				created_at,
+				MIN(time_score) OVER (ORDER BY id ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS previous_best
+			FROM "%s"
+		)
+		WHERE previous_best IS NULL OR time_score < previous_best`, mapName), mapName)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+/*
+Rebuilds the record history of the given maps, logging failures instead of stopping.
+"all" rebuilds every allowed map.
+*/
+func RebuildWRHistories(db *sql.DB, mapName string, allowedMaps []config.MapInfo) {
+	var mapList []string
+	if mapName == "all" {
+		for _, maap := range allowedMaps {
+			mapList = append(mapList, maap.MapName)
+		}
+	} else {
+		mapList = append(mapList, mapName)
+	}
+
+	for _, mapNamee := range mapList {
+		if err := RebuildWRHistory(db, mapNamee, allowedMaps); err != nil {
+			log.Printf("[DISCORD] Failed to rebuild WR history for %s: %v", mapNamee, err)
+		}
+	}
+}
+
+/*
+Returns the records of a map, oldest first.
+*/
+func WRProgressionReader(db *sql.DB, mapName string, allowedMaps []config.MapInfo) []RunRecord {
+	if !IsValidTable(mapName, allowedMaps) {
+		log.Printf("[DISCORD] Attempted to query an invalid table name: %s", mapName)
+		return nil
+	}
+
+	rows, err := db.Query(`
+		SELECT run_id, player_name, time_score, achieved_at
+		FROM wr_history
+		WHERE map_name = ?
+		ORDER BY run_id ASC`, mapName)
+	if err != nil {
+		log.Printf("[DISCORD] Failed to retrieve WR progression on map %s: %v", mapName, err)
+		return nil
+	}
+	defer rows.Close()
+
+	return scanRunRecords(rows, mapName)
+}
+
+/*
+Returns the records of a map with how long each one stood, oldest first.
+*/
+func WRHistoryReader(db *sql.DB, mapName string, allowedMaps []config.MapInfo) []WRHistoryEntry {
+	records := WRProgressionReader(db, mapName, allowedMaps)
+
+	entries := make([]WRHistoryEntry, 0, len(records))
+	for i, record := range records {
+		entry := WRHistoryEntry{RunRecord: record}
+		if i == len(records)-1 {
+			entry.Current = true
+		} else {
+			entry.ReignEnd = records[i+1].RecordedAt
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func insertWRHistory(tx *sql.Tx, mapName string, run RunRecord) error {
+	var achievedAt sql.NullInt64
+	if !run.RecordedAt.IsZero() {
+		achievedAt = sql.NullInt64{Int64: run.RecordedAt.Unix(), Valid: true}
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO wr_history (map_name, run_id, player_name, time_score, achieved_at)
+		VALUES (?, ?, ?, ?, ?)`, mapName, run.ID, run.PlayerName, run.TimeScore, achievedAt)
+	return err
+}
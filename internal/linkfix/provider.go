@@ -0,0 +1,162 @@
+package linkfix
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/*
+A site whose links embed badly in Discord and the mirror that fixes them.
+The capture group of the pattern is put in place of the %s of the replacement.
+*/
+type Provider struct {
+	Name         string
+	Pattern      string
+	Replacement  string
+	CaptureGroup int
+	Enabled      bool
+}
+
+/*
+The providers available out of the box.
+*/
+func DefaultProviders() []Provider {
+	return []Provider{
+		{
+			Name:         "Twitter/X",
+			Pattern:      `https?://(?:www\.)?(twitter|x)\.com/(\w+/status/\d+)`,
+			Replacement:  "https://fxtwitter.com/%s",
+			CaptureGroup: 2,
+			Enabled:      true,
+		},
+		{
+			Name:         "Reddit",
+			Pattern:      `https?://(?:www\.)?reddit\.com(/r/\w+/comments/\w+[^?\s]*)`,
+			Replacement:  "https://rxddit.com%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+		{
+			Name:         "TikTok",
+			Pattern:      `https?://(?:www\.)?tiktok\.com/(@[\w.-]+/video/\d+)`,
+			Replacement:  "https://vxtiktok.com/%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+		{
+			Name:         "TikTok short links",
+			Pattern:      `https?://vm\.tiktok\.com/(\w+)`,
+			Replacement:  "https://vm.vxtiktok.com/%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+		{
+			Name:         "Instagram",
+			Pattern:      `https?://(?:www\.)?instagram\.com/((?:p|reels?|tv)/[\w-]+)`,
+			Replacement:  "https://kkinstagram.com/%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+		{
+			Name:         "Bluesky",
+			Pattern:      `https?://bsky\.app/(profile/[\w.:-]+/post/\w+)`,
+			Replacement:  "https://fxbsky.app/%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+		{
+			Name:         "Twitch clips",
+			Pattern:      `https?://(?:clips\.twitch\.tv/|(?:www\.)?twitch\.tv/\w+/clip/)([\w-]+)`,
+			Replacement:  "https://fxtwitch.seria.moe/clip/%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+		{
+			// Shorts open in the vertical player, a regular video link embeds with its player.
+			Name:         "YouTube Shorts",
+			Pattern:      `https?://(?:www\.|m\.)?youtube\.com/shorts/([\w-]{11})`,
+			Replacement:  "https://www.youtube.com/watch?v=%s",
+			CaptureGroup: 1,
+			Enabled:      true,
+		},
+	}
+}
+
+type compiledProvider struct {
+	Provider
+	regex *regexp.Regexp
+}
+
+func compileProvider(provider Provider) (*compiledProvider, error) {
+	if strings.TrimSpace(provider.Name) == "" {
+		return nil, fmt.Errorf("provider for %q has no name", provider.Pattern)
+	}
+	regex, err := regexp.Compile(provider.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("provider %q has an invalid pattern: %w", provider.Name, err)
+	}
+	if provider.CaptureGroup < 1 || provider.CaptureGroup > regex.NumSubexp() {
+		return nil, fmt.Errorf("provider %q uses capture group %d but its pattern has %d", provider.Name, provider.CaptureGroup, regex.NumSubexp())
+	}
+	if strings.Count(provider.Replacement, "%s") != 1 || strings.Count(provider.Replacement, "%") != 1 {
+		return nil, fmt.Errorf("provider %q needs exactly one %%s in its replacement, where the captured part of the link goes", provider.Name)
+	}
+	return &compiledProvider{Provider: provider, regex: regex}, nil
+}
+
+/*
+Returns why the provider can't be used, nil when it is valid.
+*/
+func ValidateProvider(provider Provider) error {
+	_, err := compileProvider(provider)
+	return err
+}
+
+/*
+Rewrites links with the enabled providers, it fixes nothing until they are set.
+The providers can be replaced while messages are being fixed.
+*/
+type Fixer struct {
+	mu        sync.RWMutex
+	providers []*compiledProvider
+}
+
+/*
+Replaces the providers, keeping the current ones when any of the new providers is invalid.
+Disabled providers are validated too so they can be turned back on safely.
+*/
+func (f *Fixer) SetProviders(providers []Provider) error {
+	var compiled []*compiledProvider
+	for _, provider := range providers {
+		ready, err := compileProvider(provider)
+		if err != nil {
+			return err
+		}
+		if provider.Enabled {
+			compiled = append(compiled, ready)
+		}
+	}
+
+	f.mu.Lock()
+	f.providers = compiled
+	f.mu.Unlock()
+	return nil
+}
+
+/*
+Returns the fixed version of every link of the message, in provider order.
+*/
+func (f *Fixer) Fix(content string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var fixed []string
+	for _, provider := range f.providers {
+		for _, match := range provider.regex.FindAllStringSubmatch(content, -1) {
+			fixed = append(fixed, fmt.Sprintf(provider.Replacement, match[provider.CaptureGroup]))
+		}
+	}
+	return fixed
+}
@@ -0,0 +1,184 @@
+package linkfix
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type providerCase struct {
+	provider string
+	link     string
+	want     string
+	noMatch  string // A link the provider must leave alone
+}
+
+func TestDefaultProviders(t *testing.T) {
+	tests := []providerCase{
+		{
+			provider: "Twitter/X",
+			link:     "https://x.com/GoldenSapling/status/1790000000000000000?s=20",
+			want:     "https://fxtwitter.com/GoldenSapling/status/1790000000000000000",
+			noMatch:  "https://x.com/GoldenSapling",
+		},
+		{
+			provider: "Twitter/X",
+			link:     "https://www.twitter.com/GoldenSapling/status/123",
+			want:     "https://fxtwitter.com/GoldenSapling/status/123",
+			noMatch:  "https://fxtwitter.com/GoldenSapling/status/123",
+		},
+		{
+			provider: "Reddit",
+			link:     "https://www.reddit.com/r/apexlegends/comments/abc123/new_movement_tech/?utm_source=share",
+			want:     "https://rxddit.com/r/apexlegends/comments/abc123/new_movement_tech/",
+			noMatch:  "https://www.reddit.com/r/apexlegends/",
+		},
+		{
+			provider: "TikTok",
+			link:     "https://www.tiktok.com/@some.user/video/7300000000000000000",
+			want:     "https://vxtiktok.com/@some.user/video/7300000000000000000",
+			noMatch:  "https://www.tiktok.com/@some.user",
+		},
+		{
+			provider: "TikTok short links",
+			link:     "https://vm.tiktok.com/ZMabc123/",
+			want:     "https://vm.vxtiktok.com/ZMabc123",
+			noMatch:  "https://www.tiktok.com/ZMabc123",
+		},
+		{
+			provider: "Instagram",
+			link:     "https://www.instagram.com/reel/C1a2B3c4D5e/?igsh=xyz",
+			want:     "https://kkinstagram.com/reel/C1a2B3c4D5e",
+			noMatch:  "https://www.instagram.com/someuser/",
+		},
+		{
+			provider: "Bluesky",
+			link:     "https://bsky.app/profile/someone.bsky.social/post/3kabc123xyz",
+			want:     "https://fxbsky.app/profile/someone.bsky.social/post/3kabc123xyz",
+			noMatch:  "https://bsky.app/profile/someone.bsky.social",
+		},
+		{
+			provider: "Twitch clips",
+			link:     "https://clips.twitch.tv/FunnyClipName-AbCdEf123",
+			want:     "https://fxtwitch.seria.moe/clip/FunnyClipName-AbCdEf123",
+			noMatch:  "https://www.twitch.tv/somestreamer",
+		},
+		{
+			provider: "Twitch clips",
+			link:     "https://www.twitch.tv/somestreamer/clip/FunnyClipName-AbCdEf123?filter=clips",
+			want:     "https://fxtwitch.seria.moe/clip/FunnyClipName-AbCdEf123",
+			noMatch:  "https://www.twitch.tv/videos/2000000000",
+		},
+		{
+			provider: "YouTube Shorts",
+			link:     "https://youtube.com/shorts/dQw4w9WgXcQ?feature=share",
+			want:     "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			noMatch:  "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		},
+	}
+
+	providers := make(map[string]Provider)
+	for _, provider := range DefaultProviders() {
+		providers[provider.Name] = provider
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			provider, ok := providers[tt.provider]
+			if !ok {
+				t.Fatalf("no default provider named %q", tt.provider)
+			}
+			var fixer Fixer
+			if err := fixer.SetProviders([]Provider{provider}); err != nil {
+				t.Fatal(err)
+			}
+
+			got := fixer.Fix("look at this " + tt.link + " lol")
+			if len(got) != 1 || got[0] != tt.want {
+				t.Fatalf("Fix(%q) = %q, want [%q]", tt.link, got, tt.want)
+			}
+			if got := fixer.Fix("look at this " + tt.noMatch); len(got) != 0 {
+				t.Fatalf("Fix(%q) = %q, want no fixed links", tt.noMatch, got)
+			}
+		})
+	}
+
+	// Every default provider is covered by the table.
+	for name := range providers {
+		if !slices.ContainsFunc(tests, func(tt providerCase) bool { return tt.provider == name }) {
+			t.Errorf("default provider %q has no test case", name)
+		}
+	}
+}
+
+func TestCompileProviderRejects(t *testing.T) {
+	valid := Provider{
+		Name:         "Example",
+		Pattern:      `https?://example\.com/(\w+)`,
+		Replacement:  "https://fixexample.com/%s",
+		CaptureGroup: 1,
+	}
+	if err := ValidateProvider(valid); err != nil {
+		t.Fatalf("ValidateProvider(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *Provider)
+		want   string
+	}{
+		{"empty name", func(p *Provider) { p.Name = "  " }, "has no name"},
+		{"invalid pattern", func(p *Provider) { p.Pattern = `https://example\.com/(\w+` }, "invalid pattern"},
+		{"capture group zero", func(p *Provider) { p.CaptureGroup = 0 }, "capture group 0"},
+		{"capture group past the pattern", func(p *Provider) { p.CaptureGroup = 2 }, "capture group 2"},
+		{"missing %s", func(p *Provider) { p.Replacement = "https://fixexample.com/" }, "exactly one %s"},
+		{"two %s", func(p *Provider) { p.Replacement = "https://fixexample.com/%s/%s" }, "exactly one %s"},
+		{"extra %", func(p *Provider) { p.Replacement = "https://fixexample.com/%s?ref=100%25" }, "exactly one %s"},
+		{"other verb", func(p *Provider) { p.Replacement = "https://fixexample.com/%d" }, "exactly one %s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := valid
+			tt.change(&provider)
+			err := ValidateProvider(provider)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ValidateProvider = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixerSetProviders(t *testing.T) {
+	var fixer Fixer
+	if got := fixer.Fix("https://x.com/someone/status/1"); len(got) != 0 {
+		t.Fatalf("Fix without providers = %q, want nothing", got)
+	}
+
+	providers := DefaultProviders()
+	if err := fixer.SetProviders(providers); err != nil {
+		t.Fatal(err)
+	}
+	content := "https://bsky.app/profile/a.b/post/xyz and https://x.com/someone/status/1"
+	want := []string{"https://fxtwitter.com/someone/status/1", "https://fxbsky.app/profile/a.b/post/xyz"}
+	if got := fixer.Fix(content); !slices.Equal(got, want) {
+		t.Fatalf("Fix = %q, want %q in provider order", got, want)
+	}
+
+	// An invalid provider keeps the current ones, even when it is disabled.
+	invalid := append(slices.Clone(providers), Provider{Name: "Broken", Pattern: "(", Replacement: "%s", CaptureGroup: 1})
+	if err := fixer.SetProviders(invalid); err == nil {
+		t.Fatal("SetProviders accepted an invalid provider")
+	}
+	if got := fixer.Fix(content); !slices.Equal(got, want) {
+		t.Fatalf("Fix after a rejected update = %q, want %q", got, want)
+	}
+
+	providers[0].Enabled = false
+	if err := fixer.SetProviders(providers); err != nil {
+		t.Fatal(err)
+	}
+	if got := fixer.Fix(content); !slices.Equal(got, want[1:]) {
+		t.Fatalf("Fix with Twitter/X disabled = %q, want %q", got, want[1:])
+	}
+}
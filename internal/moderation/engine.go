@@ -0,0 +1,147 @@
+package moderation
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+The outcome of a message breaking a rule.
+*/
+type Verdict struct {
+	Rule     string
+	Action   Action
+	Timeout  time.Duration // Only used by the timeout action
+	Offenses int           // Offenses of the author within the escalation window, this one included
+}
+
+/*
+A rule a message breaks, as reported by Test.
+*/
+type Match struct {
+	Rule    string
+	Action  Action
+	Timeout time.Duration
+}
+
+/*
+Evaluates messages against the rules and escalates the action for repeat offenders.
+The rules can be replaced while messages are being evaluated.
+*/
+type Engine struct {
+	escalationWindow time.Duration // Zero disables escalation
+
+	rulesMu sync.RWMutex
+	rules   []*compiledRule
+
+	mu       sync.Mutex
+	offenses map[string][]time.Time // User ID -> times of their recent offenses
+}
+
+/*
+Compiles the rules, failing on the first invalid one.
+*/
+func NewEngine(rules []Rule, escalationWindow time.Duration) (*Engine, error) {
+	engine := &Engine{
+		escalationWindow: escalationWindow,
+		offenses:         make(map[string][]time.Time),
+	}
+	if err := engine.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+/*
+Replaces the rules, keeping the current ones when any of the new rules is invalid.
+Offenses recorded so far still count towards escalation.
+*/
+func (e *Engine) SetRules(rules []Rule) error {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		ready, err := compileRule(rule)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, ready)
+	}
+
+	e.rulesMu.Lock()
+	e.rules = compiled
+	e.rulesMu.Unlock()
+	return nil
+}
+
+func (e *Engine) RuleCount() int {
+	e.rulesMu.RLock()
+	defer e.rulesMu.RUnlock()
+	return len(e.rules)
+}
+
+/*
+Returns every rule the message breaks, without recording an offense.
+*/
+func (e *Engine) Test(message string) []Match {
+	e.rulesMu.RLock()
+	defer e.rulesMu.RUnlock()
+
+	var matches []Match
+	normalized := Normalize(message)
+	for _, rule := range e.rules {
+		if rule.matches(message, normalized) {
+			matches = append(matches, Match{Rule: rule.name, Action: rule.action, Timeout: rule.timeout})
+		}
+	}
+	return matches
+}
+
+/*
+Returns the verdict of the most severe rule the message breaks, nil when it breaks none.
+Every prior offense of the author within the escalation window makes the action one step more severe.
+*/
+func (e *Engine) Evaluate(userID, message string, now time.Time) *Verdict {
+	var matched *compiledRule
+	normalized := Normalize(message)
+	e.rulesMu.RLock()
+	for _, rule := range e.rules {
+		if (matched == nil || rule.action > matched.action) && rule.matches(message, normalized) {
+			matched = rule
+		}
+	}
+	e.rulesMu.RUnlock()
+	if matched == nil {
+		return nil
+	}
+
+	verdict := &Verdict{Rule: matched.name, Action: matched.action, Timeout: matched.timeout, Offenses: 1}
+	if e.escalationWindow > 0 {
+		verdict.Offenses = e.recordOffense(userID, now)
+		verdict.Action = min(verdict.Action+Action(verdict.Offenses-1), ActionBan)
+	}
+	return verdict
+}
+
+/*
+Records an offense and returns how many the user has within the escalation window.
+*/
+func (e *Engine) recordOffense(userID string, now time.Time) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var recent []time.Time
+	for _, offense := range e.offenses[userID] {
+		if now.Sub(offense) < e.escalationWindow {
+			recent = append(recent, offense)
+		}
+	}
+	recent = append(recent, now)
+	e.offenses[userID] = recent
+
+	// Keeps the map from growing with users who behaved since.
+	for user, times := range e.offenses {
+		if now.Sub(times[len(times)-1]) >= e.escalationWindow {
+			delete(e.offenses, user)
+		}
+	}
+	return len(recent)
+}
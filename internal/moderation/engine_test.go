@@ -0,0 +1,151 @@
+package moderation
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEvaluateSeverity(t *testing.T) {
+	engine, err := NewEngine([]Rule{
+		{Name: "Invites", Match: MatchContains, Pattern: "discord.gg/", Action: "delete"},
+		{Name: "Scam", Match: MatchNormalized, Pattern: "free nitro", Action: "ban"},
+		{Name: "Caps", Match: MatchRegex, Pattern: `[A-Z]{10,}`, Action: "timeout", Timeout: "10m"},
+		{Name: "Nitro", Match: MatchWord, Pattern: "nitro", Action: "ban"},
+		{Name: "Mass ping", Match: MatchWord, Pattern: "@everyone", Action: "kick"},
+	}, 0)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	tests := []struct {
+		message    string
+		wantRule   string // Empty when the message breaks no rule
+		wantAction Action
+	}{
+		{"gg everyone", "", 0},
+		{"join discord.gg/abc", "Invites", ActionDelete},
+		{"CHECKTHISOUT discord.gg/abc", "Caps", ActionTimeout},
+		// Both ban rules match, the first one listed wins.
+		{"Frее N1tr0 at discord.gg/abc", "Scam", ActionBan},
+		{"hey @everyone look", "Mass ping", ActionKick},
+		{"hey@everyone look", "", 0},
+	}
+
+	for _, test := range tests {
+		verdict := engine.Evaluate("user", test.message, time.Now())
+		if test.wantRule == "" {
+			if verdict != nil {
+				t.Fatalf("Evaluate(%q) = %+v, want nil", test.message, verdict)
+			}
+			continue
+		}
+		if verdict == nil {
+			t.Fatalf("Evaluate(%q) = nil, want rule %q", test.message, test.wantRule)
+		}
+		if verdict.Rule != test.wantRule || verdict.Action != test.wantAction || verdict.Offenses != 1 {
+			t.Fatalf("Evaluate(%q) = %s/%v/%d, want %s/%v/1", test.message, verdict.Rule, verdict.Action, verdict.Offenses, test.wantRule, test.wantAction)
+		}
+	}
+}
+
+func TestEvaluateEscalation(t *testing.T) {
+	engine, err := NewEngine([]Rule{
+		{Name: "Invites", Match: MatchContains, Pattern: "discord.gg/", Action: "delete"},
+		{Name: "Spam", Match: MatchContains, Pattern: "buy now", Action: "timeout"},
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		offset       time.Duration
+		user         string
+		message      string
+		wantAction   Action
+		wantOffenses int
+	}{
+		{0, "alice", "discord.gg/a", ActionDelete, 1},
+		{10 * time.Minute, "alice", "discord.gg/b", ActionTimeout, 2},
+		// Other users don't share the count.
+		{15 * time.Minute, "bob", "discord.gg/c", ActionDelete, 1},
+		{20 * time.Minute, "alice", "discord.gg/d", ActionKick, 3},
+		{30 * time.Minute, "alice", "buy now", ActionBan, 4},
+		// Capped at ban.
+		{40 * time.Minute, "alice", "discord.gg/e", ActionBan, 5},
+		// The offenses at +0 and +10m left the window, the one exactly an hour old too.
+		{80 * time.Minute, "alice", "discord.gg/f", ActionKick, 3},
+		// Every offense left the window.
+		{3 * time.Hour, "alice", "discord.gg/g", ActionDelete, 1},
+	}
+
+	for _, step := range steps {
+		verdict := engine.Evaluate(step.user, step.message, start.Add(step.offset))
+		if verdict == nil {
+			t.Fatalf("Evaluate(%s, %q) at +%v = nil", step.user, step.message, step.offset)
+		}
+		if verdict.Action != step.wantAction || verdict.Offenses != step.wantOffenses {
+			t.Fatalf("Evaluate(%s, %q) at +%v = %v/%d, want %v/%d", step.user, step.message, step.offset,
+				verdict.Action, verdict.Offenses, step.wantAction, step.wantOffenses)
+		}
+	}
+}
+
+func TestEvaluateWithoutEscalation(t *testing.T) {
+	engine, err := NewEngine([]Rule{{Name: "Invites", Match: MatchContains, Pattern: "discord.gg/", Action: "delete"}}, 0)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	now := time.Now()
+	for n := range 3 {
+		verdict := engine.Evaluate("alice", "discord.gg/a", now.Add(time.Duration(n)*time.Minute))
+		if verdict == nil || verdict.Action != ActionDelete || verdict.Offenses != 1 {
+			t.Fatalf("Evaluate() #%d = %+v, want delete with 1 offense", n+1, verdict)
+		}
+	}
+	if len(engine.offenses) != 0 {
+		t.Fatalf("offenses = %v, want none recorded", engine.offenses)
+	}
+}
+
+func TestRecordOffensePruning(t *testing.T) {
+	engine, err := NewEngine(nil, time.Hour)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		offset    time.Duration
+		user      string
+		wantCount int
+		wantUsers []string // Users still tracked after the offense
+	}{
+		{0, "alice", 1, []string{"alice"}},
+		{30 * time.Minute, "bob", 1, []string{"alice", "bob"}},
+		{50 * time.Minute, "alice", 2, []string{"alice", "bob"}},
+		// Bob's only offense is an hour old, he is dropped.
+		{90 * time.Minute, "carol", 1, []string{"alice", "carol"}},
+		// Alice's first offense left the window, the second one is kept.
+		{100 * time.Minute, "alice", 2, []string{"alice", "carol"}},
+		{4 * time.Hour, "dave", 1, []string{"dave"}},
+	}
+
+	for _, step := range steps {
+		if got := engine.recordOffense(step.user, start.Add(step.offset)); got != step.wantCount {
+			t.Fatalf("recordOffense(%s) at +%v = %d, want %d", step.user, step.offset, got, step.wantCount)
+		}
+		var users []string
+		for user := range engine.offenses {
+			users = append(users, user)
+		}
+		slices.Sort(users)
+		if !slices.Equal(users, step.wantUsers) {
+			t.Fatalf("tracked users at +%v = %v, want %v", step.offset, users, step.wantUsers)
+		}
+		if got := len(engine.offenses[step.user]); got != step.wantCount {
+			t.Fatalf("offenses of %s at +%v = %d, want %d", step.user, step.offset, got, step.wantCount)
+		}
+	}
+}
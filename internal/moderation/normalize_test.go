@@ -0,0 +1,36 @@
+package moderation
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"Free Nitro", "freenitro"},
+		{"f.r.e.e-n.i.t.r.o", "freenitro"},
+		// Leetspeak
+		{"Fr33 N1tr0", "freenitro"},
+		{"$t3@m g1ft", "steamgift"},
+		{"|0|", "lol"},
+		// Cyrillic and greek look-alikes
+		{"Frее Nіtrо", "freenitro"},
+		{"ΤΟΡ ΚΙΤ", "topkit"},
+		{"Ѕtеаm", "steam"},
+		// Accents
+		{"Frée Nïtrô", "freenitro"},
+		// Fullwidth
+		{"ＦＲＥＥ　ｎｉｔｒｏ", "freenitro"},
+		{"ｆ１ｒ３", "fire"},
+		// Everything else is dropped
+		{"free 🎁 nitro!!", "freenitroii"},
+		{"日本語 gg", "gg"},
+	}
+
+	for _, test := range tests {
+		if got := Normalize(test.text); got != test.want {
+			t.Fatalf("Normalize(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Action int
+
+// Ordered by severity, escalation moves an action down this list.
+const (
+	ActionDelete Action = iota
+	ActionTimeout
+	ActionKick
+	ActionBan
+)
+
+// Timeout used by rules that don't set their own.
+const DefaultTimeout = time.Hour
+
+var actionNames = map[Action]string{
+	ActionDelete:  "delete",
+	ActionTimeout: "timeout",
+	ActionKick:    "kick",
+	ActionBan:     "ban",
+}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+func ParseAction(name string) (Action, error) {
+	for action, actionName := range actionNames {
+		if strings.EqualFold(name, actionName) {
+			return action, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown action %q, expected delete, timeout, kick or ban", name)
+}
+
+type MatchKind string
+
+const (
+	MatchWord       MatchKind = "word"       // Whole words only, case insensitive
+	MatchRegex      MatchKind = "regex"      // Go regular expression, case sensitive unless it starts with (?i)
+	MatchNormalized MatchKind = "normalized" // Ignores case, leetspeak, look-alike letters and separators
+	MatchContains   MatchKind = "contains"   // Anywhere in the message, case insensitive
+)
+
+/*
+A moderation rule, as written in the rules file.
+*/
+type Rule struct {
+	Name    string    `json:"name"`
+	Match   MatchKind `json:"match"`
+	Pattern string    `json:"pattern"`
+	Action  string    `json:"action"`
+	Timeout string    `json:"timeout,omitempty"` // Duration of the timeout action, e.g. "10m"
+}
+
+/*
+A rule ready to be evaluated.
+*/
+type compiledRule struct {
+	name    string
+	action  Action
+	timeout time.Duration
+	matches func(message, normalized string) bool
+}
+
+func compileRule(rule Rule) (*compiledRule, error) {
+	if rule.Name == "" {
+		rule.Name = rule.Pattern
+	}
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return nil, fmt.Errorf("rule %q has no pattern", rule.Name)
+	}
+	action, err := ParseAction(rule.Action)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+	compiled := &compiledRule{name: rule.Name, action: action, timeout: DefaultTimeout}
+	if rule.Timeout != "" {
+		compiled.timeout, err = time.ParseDuration(rule.Timeout)
+		if err != nil || compiled.timeout <= 0 {
+			return nil, fmt.Errorf("rule %q has an invalid timeout %q", rule.Name, rule.Timeout)
+		}
+	}
+
+	switch rule.Match {
+	case MatchWord:
+		re, err := regexp.Compile(`(?i)(?:^|[^\pL\pN_])` + regexp.QuoteMeta(rule.Pattern) + `(?:$|[^\pL\pN_])`)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		compiled.matches = func(message, _ string) bool { return re.MatchString(message) }
+	case MatchRegex:
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q has an invalid regex: %w", rule.Name, err)
+		}
+		compiled.matches = func(message, _ string) bool { return re.MatchString(message) }
+	case MatchNormalized:
+		pattern := Normalize(rule.Pattern)
+		if pattern == "" {
+			return nil, fmt.Errorf("rule %q has no letters or digits to match", rule.Name)
+		}
+		compiled.matches = func(_, normalized string) bool { return strings.Contains(normalized, pattern) }
+	case MatchContains, "":
+		pattern := strings.ToLower(rule.Pattern)
+		compiled.matches = func(message, _ string) bool { return strings.Contains(strings.ToLower(message), pattern) }
+	default:
+		return nil, fmt.Errorf("rule %q has an unknown match %q, expected word, regex, normalized or contains", rule.Name, rule.Match)
+	}
+
+	return compiled, nil
+}
+
+/*
+Returns why the rule can't be used, nil when it is valid.
+*/
+func ValidateRule(rule Rule) error {
+	_, err := compileRule(rule)
+	return err
+}
+
+/*
+Reads the rules from a JSON file holding an array of rules.
+*/
+func LoadRules(path string) ([]Rule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation rules: %w", err)
+	}
+	return rules, nil
+}
+
+/*
+Turns the legacy comma separated BANNED_WORDS into rules that ban on sight, as the list always did.
+The words match whole words, a leading @ counts as a word boundary so "@everyone" still matches.
+*/
+func LegacyRules(bannedWords string) []Rule {
+	var rules []Rule
+	for _, word := range strings.Split(bannedWords, ",") {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		rules = append(rules, Rule{
+			Name:    "Banned word: " + word,
+			Match:   MatchWord,
+			Pattern: word,
+			Action:  ActionBan.String(),
+		})
+	}
+	return rules
+}
@@ -0,0 +1,178 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Invite and Nitro gift links, the usual bait of scam waves.
+var inviteOrGiftLink = regexp.MustCompile(`(?i)(discord(app)?\.com/(invite|gifts)/|discord\.(gg|gift)/|discord\.me/|dsc\.gg/)`)
+
+/*
+Thresholds of the spam heuristics, a zero value disables the heuristic.
+*/
+type SpamLimits struct {
+	DuplicateChannels int           // Identical messages in this many channels (at least 2, 1 disables it too)...
+	DuplicateWindow   time.Duration // ...within this window
+	MaxMentions       int           // Mentions in a single message
+	NewAccountAge     time.Duration // Accounts younger than this can't post invite or gift links
+}
+
+/*
+A message as seen by the spam detector.
+*/
+type SpamMessage struct {
+	ID             string
+	ChannelID      string
+	AuthorID       string
+	AccountCreated time.Time
+	Content        string
+	Attachments    []string // File names, a scam image posted everywhere has no text
+	Mentions       int
+}
+
+/*
+The outcome of a message flagged as spam.
+*/
+type SpamVerdict struct {
+	Reason string
+	// The author was already flagged moments ago, the message only has to be deleted.
+	AlreadyFlagged bool
+	// Recent messages of the author to delete, this one included, by channel ID.
+	Messages map[string][]string
+}
+
+type trackedMessage struct {
+	id        string
+	channelID string
+	content   string
+	postedAt  time.Time
+}
+
+/*
+Flags compromised accounts posting the same message everywhere, mass mentions and link bait from new accounts.
+It remembers the recent messages of every user so they can all be deleted once they are flagged.
+*/
+type SpamDetector struct {
+	limits SpamLimits
+
+	mu      sync.Mutex
+	recent  map[string][]trackedMessage // User ID -> their recent messages
+	flagged map[string]time.Time        // User ID -> when they were flagged
+}
+
+func NewSpamDetector(limits SpamLimits) *SpamDetector {
+	return &SpamDetector{
+		limits:  limits,
+		recent:  make(map[string][]trackedMessage),
+		flagged: make(map[string]time.Time),
+	}
+}
+
+/*
+Records the message and returns a verdict when it, or the messages posted before it, look like spam.
+*/
+func (d *SpamDetector) Check(message SpamMessage, now time.Time) *SpamVerdict {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(now)
+
+	if _, ok := d.flagged[message.AuthorID]; ok {
+		return &SpamVerdict{
+			AlreadyFlagged: true,
+			Messages:       map[string][]string{message.ChannelID: {message.ID}},
+		}
+	}
+
+	content := spamFingerprint(message)
+	d.recent[message.AuthorID] = append(d.recent[message.AuthorID], trackedMessage{
+		id:        message.ID,
+		channelID: message.ChannelID,
+		content:   content,
+		postedAt:  now,
+	})
+
+	reason := d.reason(message, content, now)
+	if reason == "" {
+		return nil
+	}
+
+	verdict := &SpamVerdict{Reason: reason, Messages: make(map[string][]string)}
+	for _, tracked := range d.recent[message.AuthorID] {
+		verdict.Messages[tracked.channelID] = append(verdict.Messages[tracked.channelID], tracked.id)
+	}
+	delete(d.recent, message.AuthorID)
+	d.flagged[message.AuthorID] = now
+	return verdict
+}
+
+func (d *SpamDetector) reason(message SpamMessage, content string, now time.Time) string {
+	if d.limits.MaxMentions > 0 && message.Mentions >= d.limits.MaxMentions {
+		return fmt.Sprintf("Mass mention: %d mentions in one message", message.Mentions)
+	}
+
+	if d.limits.NewAccountAge > 0 && now.Sub(message.AccountCreated) < d.limits.NewAccountAge && inviteOrGiftLink.MatchString(message.Content) {
+		return fmt.Sprintf("Invite or gift link from an account created %s ago", formatAge(now.Sub(message.AccountCreated)))
+	}
+
+	if d.limits.DuplicateChannels > 1 && content != "" {
+		channels := make(map[string]bool)
+		for _, tracked := range d.recent[message.AuthorID] {
+			if tracked.content == content && now.Sub(tracked.postedAt) < d.limits.DuplicateWindow {
+				channels[tracked.channelID] = true
+			}
+		}
+		if len(channels) >= d.limits.DuplicateChannels {
+			return fmt.Sprintf("Flood: the same message in %d channels within %s", len(channels), d.limits.DuplicateWindow)
+		}
+	}
+	return ""
+}
+
+/*
+Forgets messages older than the duplicate window, or a minute if shorter, and flags that expired.
+Flagged users stay flagged that long so the rest of a burst is deleted without new verdicts.
+*/
+func (d *SpamDetector) prune(now time.Time) {
+	window := max(d.limits.DuplicateWindow, time.Minute)
+	for user, messages := range d.recent {
+		kept := messages[:0]
+		for _, tracked := range messages {
+			if now.Sub(tracked.postedAt) < window {
+				kept = append(kept, tracked)
+			}
+		}
+		if len(kept) == 0 {
+			delete(d.recent, user)
+		} else {
+			d.recent[user] = kept
+		}
+	}
+	for user, flaggedAt := range d.flagged {
+		if now.Sub(flaggedAt) >= window {
+			delete(d.flagged, user)
+		}
+	}
+}
+
+/*
+Returns what makes two messages identical for the flood heuristic.
+Case and spacing are ignored, so are the attachment names when there is text.
+*/
+func spamFingerprint(message SpamMessage) string {
+	content := strings.Join(strings.Fields(strings.ToLower(message.Content)), " ")
+	if content == "" {
+		content = strings.ToLower(strings.Join(message.Attachments, "\n"))
+	}
+	return content
+}
+
+func formatAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
@@ -0,0 +1,169 @@
+package moderation
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+type spamStep struct {
+	offset        time.Duration
+	message       SpamMessage
+	wantReason    string              // Empty when the message isn't flagged
+	wantFollowUp  bool                // Flagged as a follow-up of an earlier verdict
+	wantDeletions map[string][]string // Channel ID -> message IDs
+}
+
+func message(id, channelID, authorID, content string) SpamMessage {
+	return SpamMessage{ID: id, ChannelID: channelID, AuthorID: authorID, Content: content}
+}
+
+/*
+Runs the steps against a detector, the clock starts at the same time for every test.
+*/
+func runSpamSteps(t *testing.T, limits SpamLimits, steps []spamStep) {
+	t.Helper()
+	detector := NewSpamDetector(limits)
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, step := range steps {
+		if step.message.AccountCreated.IsZero() {
+			step.message.AccountCreated = start.AddDate(-1, 0, 0)
+		}
+		verdict := detector.Check(step.message, start.Add(step.offset))
+		if step.wantReason == "" && !step.wantFollowUp {
+			if verdict != nil {
+				t.Fatalf("Check(%s) at +%v = %+v, want nil", step.message.ID, step.offset, verdict)
+			}
+			continue
+		}
+		if verdict == nil {
+			t.Fatalf("Check(%s) at +%v = nil, want a verdict", step.message.ID, step.offset)
+		}
+		if verdict.Reason != step.wantReason || verdict.AlreadyFlagged != step.wantFollowUp {
+			t.Fatalf("Check(%s) at +%v = %q (follow-up %v), want %q (follow-up %v)",
+				step.message.ID, step.offset, verdict.Reason, verdict.AlreadyFlagged, step.wantReason, step.wantFollowUp)
+		}
+		if !maps.EqualFunc(verdict.Messages, step.wantDeletions, slices.Equal) {
+			t.Fatalf("Check(%s) at +%v deletes %v, want %v", step.message.ID, step.offset, verdict.Messages, step.wantDeletions)
+		}
+	}
+}
+
+func TestSpamFlood(t *testing.T) {
+	limits := SpamLimits{DuplicateChannels: 3, DuplicateWindow: 30 * time.Second}
+	scam := func(id, channelID string) SpamMessage {
+		return SpamMessage{ID: id, ChannelID: channelID, AuthorID: "carol", Attachments: []string{"scam.png"}}
+	}
+
+	runSpamSteps(t, limits, []spamStep{
+		{offset: 0, message: message("1", "general", "alice", "Free stuff")},
+		// Case and spacing are ignored.
+		{offset: 5 * time.Second, message: message("2", "memes", "alice", "free   STUFF")},
+		// The same channel twice counts once.
+		{offset: 10 * time.Second, message: message("3", "memes", "alice", "free stuff")},
+		{offset: 11 * time.Second, message: message("4", "clips", "alice", "something else")},
+		{
+			offset:        20 * time.Second,
+			message:       message("5", "clips", "alice", "free stuff"),
+			wantReason:    "Flood: the same message in 3 channels within 30s",
+			wantDeletions: map[string][]string{"general": {"1"}, "memes": {"2", "3"}, "clips": {"4", "5"}},
+		},
+		// The rest of the burst is only deleted.
+		{
+			offset:        25 * time.Second,
+			message:       message("6", "help", "alice", "free stuff"),
+			wantFollowUp:  true,
+			wantDeletions: map[string][]string{"help": {"6"}},
+		},
+		// The flag lasts a minute, the shortest memory of the detector.
+		{offset: 80 * time.Second, message: message("7", "general", "alice", "free stuff")},
+
+		// Too slow to be a flood, the first message is out of the window by the third.
+		{offset: 0, message: message("8", "general", "bob", "hello")},
+		{offset: 20 * time.Second, message: message("9", "memes", "bob", "hello")},
+		{offset: 30 * time.Second, message: message("10", "clips", "bob", "hello")},
+
+		// An image without text posted everywhere.
+		{offset: 0, message: scam("11", "general")},
+		{offset: time.Second, message: scam("12", "memes")},
+		{
+			offset:        2 * time.Second,
+			message:       scam("13", "clips"),
+			wantReason:    "Flood: the same message in 3 channels within 30s",
+			wantDeletions: map[string][]string{"general": {"11"}, "memes": {"12"}, "clips": {"13"}},
+		},
+	})
+}
+
+func TestSpamFloodDisabled(t *testing.T) {
+	for _, channels := range []int{0, 1} {
+		limits := SpamLimits{DuplicateChannels: channels, DuplicateWindow: 30 * time.Second}
+		runSpamSteps(t, limits, []spamStep{
+			{offset: 0, message: message("1", "general", "alice", "free stuff")},
+			{offset: time.Second, message: message("2", "memes", "alice", "free stuff")},
+			{offset: 2 * time.Second, message: message("3", "clips", "alice", "free stuff")},
+		})
+	}
+}
+
+func TestSpamMentions(t *testing.T) {
+	limits := SpamLimits{MaxMentions: 5}
+	mentions := func(id, authorID string, count int) SpamMessage {
+		return SpamMessage{ID: id, ChannelID: "general", AuthorID: authorID, Content: "hey", Mentions: count}
+	}
+
+	runSpamSteps(t, limits, []spamStep{
+		{offset: 0, message: mentions("1", "alice", 4)},
+		{
+			offset:        time.Second,
+			message:       mentions("2", "alice", 5),
+			wantReason:    "Mass mention: 5 mentions in one message",
+			wantDeletions: map[string][]string{"general": {"1", "2"}},
+		},
+		{
+			offset:        2 * time.Second,
+			message:       mentions("3", "alice", 0),
+			wantFollowUp:  true,
+			wantDeletions: map[string][]string{"general": {"3"}},
+		},
+		{offset: 3 * time.Second, message: mentions("4", "bob", 4)},
+		// Messages older than a minute are forgotten, only the offending one is deleted.
+		{
+			offset:        2 * time.Minute,
+			message:       mentions("5", "bob", 12),
+			wantReason:    "Mass mention: 12 mentions in one message",
+			wantDeletions: map[string][]string{"general": {"5"}},
+		},
+	})
+}
+
+func TestSpamNewAccountLinks(t *testing.T) {
+	limits := SpamLimits{NewAccountAge: 7 * 24 * time.Hour}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	from := func(id, authorID string, age time.Duration, content string) SpamMessage {
+		return SpamMessage{ID: id, ChannelID: "general", AuthorID: authorID, AccountCreated: start.Add(-age), Content: content}
+	}
+
+	runSpamSteps(t, limits, []spamStep{
+		{
+			message:       from("1", "alice", 50*time.Hour, "join discord.gg/abc"),
+			wantReason:    "Invite or gift link from an account created 2d ago",
+			wantDeletions: map[string][]string{"general": {"1"}},
+		},
+		{
+			message:       from("2", "bob", 3*time.Hour, "claim it https://discord.com/gifts/abc"),
+			wantReason:    "Invite or gift link from an account created 3h ago",
+			wantDeletions: map[string][]string{"general": {"2"}},
+		},
+		{
+			message:       from("3", "carol", time.Hour, "DISCORDAPP.COM/INVITE/abc"),
+			wantReason:    "Invite or gift link from an account created 1h ago",
+			wantDeletions: map[string][]string{"general": {"3"}},
+		},
+		// Old enough accounts and messages without links are fine.
+		{message: from("4", "dave", 8*24*time.Hour, "join discord.gg/abc")},
+		{message: from("5", "erin", time.Hour, "https://discord.com/channels/1/2")},
+	})
+}
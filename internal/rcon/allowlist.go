@@ -0,0 +1,41 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+Checks that a console command line only runs one of the allowed commands.
+The console runs every command of a line separated by ";", so those lines are refused outright.
+*/
+func CheckAllowed(line string, allowed []string) error {
+	if strings.ContainsAny(line, ";\r\n") {
+		return errors.New("only one command per line is allowed")
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return errors.New("empty command")
+	}
+	command := strings.ToLower(fields[0])
+	if !slices.Contains(allowed, command) {
+		return fmt.Errorf("%s is not an allowed command", command)
+	}
+	return nil
+}
+
+/*
+Quotes a value as a single console argument, dropping what could end the argument or the command.
+*/
+func Quote(value string) string {
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case '"', ';', '\r', '\n':
+			return -1
+		}
+		return r
+	}, value)
+	return `"` + value + `"`
+}
@@ -0,0 +1,261 @@
+/*
+Package rcon talks to the netcon (RCON) interface of the R5Reloaded dedicated server.
+
+Every frame is an 8 byte header, a magic number and the payload length as big endian uint32s,
+followed by a protobuf envelope. The envelope data is a request or response message,
+encrypted with AES-CTR when the server uses a netcon key.
+*/
+package rcon
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	frameMagic   = 'R' | 'C'<<8 | 'O'<<16 | 'N'<<24
+	maxFrameSize = 1 << 20
+
+	requestExecCommand    = 0
+	requestAuth           = 1
+	requestSendConsoleLog = 2
+
+	responseAuth       = 0
+	responseConsoleLog = 1
+)
+
+// How long Exec keeps collecting console output once the server went quiet.
+const outputIdle = 750 * time.Millisecond
+
+var ErrAuthFailed = errors.New("rcon authentication failed")
+
+type request struct {
+	ID    int32
+	Type  int32
+	Msg   string
+	Value string
+}
+
+type response struct {
+	ID    int32
+	Type  int32
+	Msg   string
+	Value string
+}
+
+type Client struct {
+	conn    net.Conn
+	key     []byte // nil when frames are not encrypted
+	timeout time.Duration
+	nextID  int32
+}
+
+/*
+Decodes a base64 netcon key, an empty key disables encryption.
+*/
+func ParseKey(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rcon key: %w", err)
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, fmt.Errorf("invalid rcon key: %w", err)
+	}
+	return key, nil
+}
+
+/*
+Connects and authenticates to the server, then subscribes to its console output.
+*/
+func Dial(address, password string, key []byte, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	c := &Client{conn: conn, key: key, timeout: timeout}
+
+	if err := c.send(requestAuth, password, ""); err != nil {
+		c.Close()
+		return nil, err
+	}
+	for {
+		resp, err := c.receive(time.Now().Add(timeout))
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("no authentication response: %w", err)
+		}
+		if resp.Type != responseAuth {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(resp.Msg), "success") {
+			c.Close()
+			return nil, fmt.Errorf("%w: %s", ErrAuthFailed, resp.Msg)
+		}
+		break
+	}
+
+	if err := c.send(requestSendConsoleLog, "", "1"); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+/*
+Runs a console command and returns the console output that followed it.
+The protocol doesn't tie output to commands, so anything the server logged meanwhile is included.
+*/
+func (c *Client) Exec(command string) (string, error) {
+	if err := c.send(requestExecCommand, command, ""); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	deadline := time.Now().Add(c.timeout)
+	for time.Now().Before(deadline) {
+		idle := time.Now().Add(outputIdle)
+		if idle.After(deadline) {
+			idle = deadline
+		}
+		resp, err := c.receive(idle)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			break // The server went quiet.
+		}
+		if err != nil {
+			return output.String(), err
+		}
+		if resp.Type == responseConsoleLog {
+			output.WriteString(resp.Msg)
+		}
+	}
+	return output.String(), nil
+}
+
+func (c *Client) send(requestType int32, msg, value string) error {
+	c.nextID++
+	data := encodeRequest(request{ID: c.nextID, Type: requestType, Msg: msg, Value: value})
+
+	var envelope []byte
+	if c.key != nil {
+		nonce := make([]byte, aes.BlockSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		envelope = appendVarintField(envelope, 1, 1)
+		envelope = appendBytesField(envelope, 2, nonce)
+		envelope = appendBytesField(envelope, 3, c.crypt(nonce, data))
+	} else {
+		envelope = appendBytesField(envelope, 3, data)
+	}
+
+	frame := binary.BigEndian.AppendUint32(nil, frameMagic)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(envelope)))
+	frame = append(frame, envelope...)
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(frame); err != nil {
+		return fmt.Errorf("failed to send rcon request: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) receive(deadline time.Time) (response, error) {
+	c.conn.SetReadDeadline(deadline)
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return response{}, err
+	}
+	if magic := binary.BigEndian.Uint32(header); magic != frameMagic {
+		return response{}, fmt.Errorf("invalid rcon frame magic %#x", magic)
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if length > maxFrameSize {
+		return response{}, fmt.Errorf("rcon frame of %d bytes is too large", length)
+	}
+	envelope := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, envelope); err != nil {
+		return response{}, err
+	}
+
+	var (
+		encrypted   bool
+		nonce, data []byte
+	)
+	err := readFields(envelope, func(field int, varint uint64, bytes []byte) {
+		switch field {
+		case 1:
+			encrypted = varint != 0
+		case 2:
+			nonce = bytes
+		case 3:
+			data = bytes
+		}
+	})
+	if err != nil {
+		return response{}, err
+	}
+	if encrypted {
+		if c.key == nil || len(nonce) != aes.BlockSize {
+			return response{}, errors.New("received an encrypted rcon frame without a usable key")
+		}
+		data = c.crypt(nonce, data)
+	}
+	return decodeResponse(data)
+}
+
+/*
+Encrypts or decrypts, AES-CTR is symmetric.
+*/
+func (c *Client) crypt(nonce, data []byte) []byte {
+	block, _ := aes.NewCipher(c.key) // The key was validated by ParseKey
+	out := make([]byte, len(data))
+	cipher.NewCTR(block, nonce).XORKeyStream(out, data)
+	return out
+}
+
+/*
+Field 2, the message type, is left out, the server only reads the request type.
+*/
+func encodeRequest(req request) []byte {
+	var buf []byte
+	buf = appendVarintField(buf, 1, uint64(uint32(req.ID)))
+	buf = appendVarintField(buf, 3, uint64(uint32(req.Type)))
+	buf = appendBytesField(buf, 4, []byte(req.Msg))
+	buf = appendBytesField(buf, 5, []byte(req.Value))
+	return buf
+}
+
+func decodeResponse(data []byte) (response, error) {
+	var resp response
+	err := readFields(data, func(field int, varint uint64, bytes []byte) {
+		switch field {
+		case 1:
+			resp.ID = int32(varint)
+		case 3:
+			resp.Type = int32(varint)
+		case 4:
+			resp.Msg = string(bytes)
+		case 5:
+			resp.Value = string(bytes)
+		}
+	})
+	return resp, err
+}
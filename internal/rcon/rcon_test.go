@@ -0,0 +1,261 @@
+package rcon
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPassword = "hunter2"
+
+/*
+A netcon server answering the requests of a single client with the frames respond returns.
+*/
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	key      []byte
+	respond  func(s *fakeServer, req request) [][]byte
+	requests chan request
+}
+
+func newFakeServer(t *testing.T, key []byte, respond func(s *fakeServer, req request) [][]byte) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{t: t, listener: listener, key: key, respond: respond, requests: make(chan request, 16)}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if binary.BigEndian.Uint32(header) != frameMagic {
+			s.t.Errorf("client sent a frame with magic %#x", binary.BigEndian.Uint32(header))
+			return
+		}
+		envelope := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(conn, envelope); err != nil {
+			return
+		}
+		req, err := s.decodeRequest(envelope)
+		if err != nil {
+			s.t.Errorf("failed to decode request: %v", err)
+			return
+		}
+		s.requests <- req
+
+		for _, frame := range s.respond(s, req) {
+			if _, err := conn.Write(frame); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *fakeServer) decodeRequest(envelope []byte) (request, error) {
+	var (
+		encrypted   bool
+		nonce, data []byte
+	)
+	err := readFields(envelope, func(field int, varint uint64, bytes []byte) {
+		switch field {
+		case 1:
+			encrypted = varint != 0
+		case 2:
+			nonce = bytes
+		case 3:
+			data = bytes
+		}
+	})
+	if err != nil {
+		return request{}, err
+	}
+	if encrypted != (s.key != nil) {
+		return request{}, errors.New("request encryption doesn't match the server key")
+	}
+	if encrypted {
+		data = crypt(s.key, nonce, data)
+	}
+
+	var req request
+	err = readFields(data, func(field int, varint uint64, bytes []byte) {
+		switch field {
+		case 1:
+			req.ID = int32(varint)
+		case 3:
+			req.Type = int32(varint)
+		case 4:
+			req.Msg = string(bytes)
+		case 5:
+			req.Value = string(bytes)
+		}
+	})
+	return req, err
+}
+
+/*
+Encodes a response frame, encrypted with the server key when there is one.
+*/
+func (s *fakeServer) frame(resp response) []byte {
+	var data []byte
+	data = appendVarintField(data, 1, uint64(uint32(resp.ID)))
+	data = appendVarintField(data, 3, uint64(uint32(resp.Type)))
+	data = appendBytesField(data, 4, []byte(resp.Msg))
+	data = appendBytesField(data, 5, []byte(resp.Value))
+
+	var envelope []byte
+	if s.key != nil {
+		nonce := make([]byte, aes.BlockSize)
+		rand.Read(nonce)
+		envelope = appendVarintField(envelope, 1, 1)
+		envelope = appendBytesField(envelope, 2, nonce)
+		envelope = appendBytesField(envelope, 3, crypt(s.key, nonce, data))
+	} else {
+		envelope = appendBytesField(envelope, 3, data)
+	}
+
+	frame := binary.BigEndian.AppendUint32(nil, frameMagic)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(envelope)))
+	return append(frame, envelope...)
+}
+
+func crypt(key, nonce, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCTR(block, nonce).XORKeyStream(out, data)
+	return out
+}
+
+/*
+Answers like the game server: checks the password and echoes commands to the console log.
+*/
+func consoleServer(s *fakeServer, req request) [][]byte {
+	switch req.Type {
+	case requestAuth:
+		if req.Msg != testPassword {
+			return [][]byte{s.frame(response{ID: req.ID, Type: responseAuth, Msg: "Admin password incorrect"})}
+		}
+		return [][]byte{s.frame(response{ID: req.ID, Type: responseAuth, Msg: "Authentication successful"})}
+	case requestExecCommand:
+		return [][]byte{
+			s.frame(response{Type: responseConsoleLog, Msg: "] " + req.Msg + "\n"}),
+			s.frame(response{Type: responseConsoleLog, Msg: "hostname: GoldenSapling\n"}),
+		}
+	}
+	return nil
+}
+
+func TestDialAndExec(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	for name, key := range map[string][]byte{"plain": nil, "encrypted": key} {
+		t.Run(name, func(t *testing.T) {
+			server := newFakeServer(t, key, consoleServer)
+			client, err := Dial(server.address(), testPassword, key, 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			auth := <-server.requests
+			if auth.Type != requestAuth || auth.Msg != testPassword {
+				t.Fatalf("first request = %+v, want auth with the password", auth)
+			}
+			subscribe := <-server.requests
+			if subscribe.Type != requestSendConsoleLog || subscribe.Value != "1" {
+				t.Fatalf("second request = %+v, want the console log subscription", subscribe)
+			}
+
+			output, err := client.Exec("hostname")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "] hostname\nhostname: GoldenSapling\n"; output != want {
+				t.Fatalf("Exec output = %q, want %q", output, want)
+			}
+			if exec := <-server.requests; exec.Type != requestExecCommand || exec.Msg != "hostname" {
+				t.Fatalf("exec request = %+v, want the hostname command", exec)
+			}
+		})
+	}
+}
+
+func TestDialWrongPassword(t *testing.T) {
+	server := newFakeServer(t, nil, consoleServer)
+	_, err := Dial(server.address(), "wrong", nil, 5*time.Second)
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("Dial with a wrong password = %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	// Never answers the authentication.
+	server := newFakeServer(t, nil, func(s *fakeServer, req request) [][]byte { return nil })
+
+	started := time.Now()
+	_, err := Dial(server.address(), testPassword, nil, 200*time.Millisecond)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Dial to a silent server = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("Dial took %v to time out", elapsed)
+	}
+}
+
+func TestDialInvalidFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{
+			name:   "bad magic",
+			header: binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, 0xdeadbeef), 4),
+			want:   "invalid rcon frame magic",
+		},
+		{
+			name:   "oversized",
+			header: binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, frameMagic), maxFrameSize+1),
+			want:   "too large",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, nil, func(s *fakeServer, req request) [][]byte {
+				return [][]byte{tt.header}
+			})
+			_, err := Dial(server.address(), testPassword, nil, 5*time.Second)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Dial = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// A Sunday.
+var cronStart = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       []time.Time // Successive runs after cronStart
+	}{
+		{"*/20 * * * *", []time.Time{at(6, 1, 0, 20), at(6, 1, 0, 40), at(6, 1, 1, 0)}},
+		{"5-50/15 9 * * *", []time.Time{at(6, 1, 9, 5), at(6, 1, 9, 20), at(6, 1, 9, 35), at(6, 1, 9, 50), at(6, 2, 9, 5)}},
+		// A step after a single value runs to the end of the field.
+		{"0 20/2 * * *", []time.Time{at(6, 1, 20, 0), at(6, 1, 22, 0), at(6, 2, 20, 0)}},
+		{"0 8,12-13 * * *", []time.Time{at(6, 1, 8, 0), at(6, 1, 12, 0), at(6, 1, 13, 0), at(6, 2, 8, 0)}},
+		// Names, in any case.
+		{"0 18 * JUL-aug sat", []time.Time{at(7, 5, 18, 0), at(7, 12, 18, 0), at(7, 19, 18, 0)}},
+		{"0 9 * * mon-fri", []time.Time{at(6, 2, 9, 0), at(6, 3, 9, 0), at(6, 4, 9, 0), at(6, 5, 9, 0), at(6, 6, 9, 0), at(6, 9, 9, 0)}},
+		// Sunday is both 0 and 7.
+		{"0 10 * * 7", []time.Time{at(6, 1, 10, 0), at(6, 8, 10, 0)}},
+		{"0 10 * * 5-7", []time.Time{at(6, 1, 10, 0), at(6, 6, 10, 0), at(6, 7, 10, 0), at(6, 8, 10, 0)}},
+		// With both days restricted, either of them matching is enough.
+		{"0 12 13 * fri", []time.Time{at(6, 6, 12, 0), at(6, 13, 12, 0), at(6, 20, 12, 0), at(6, 27, 12, 0), at(7, 4, 12, 0), at(7, 11, 12, 0), at(7, 13, 12, 0)}},
+		// A * day field, stepped or not, only leaves the weekday to match.
+		{"0 12 */2 * fri", []time.Time{at(6, 13, 12, 0), at(6, 27, 12, 0), at(7, 11, 12, 0)}},
+		{"0 12 13 * *", []time.Time{at(6, 13, 12, 0), at(7, 13, 12, 0)}},
+		// Short months are skipped.
+		{"0 0 31 * *", []time.Time{at(7, 31, 0, 0), at(8, 31, 0, 0), at(10, 31, 0, 0)}},
+		{"0 0 29 2 *", []time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)}},
+		// Shortcuts
+		{"@hourly", []time.Time{at(6, 1, 1, 0), at(6, 1, 2, 0)}},
+		{"@daily", []time.Time{at(6, 2, 0, 0), at(6, 3, 0, 0)}},
+		{"@weekly", []time.Time{at(6, 8, 0, 0), at(6, 15, 0, 0)}},
+		{" @Monthly ", []time.Time{at(7, 1, 0, 0), at(8, 1, 0, 0)}},
+	}
+
+	for _, test := range tests {
+		cron, err := Parse(test.expression)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", test.expression, err)
+		}
+		next := cronStart
+		for _, want := range test.want {
+			next = cron.Next(next)
+			if !next.Equal(want) {
+				t.Fatalf("%q: Next = %v, want %v", test.expression, next, want)
+			}
+		}
+	}
+}
+
+func TestCronParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantError  string
+	}{
+		{"", "needs 5 fields"},
+		{"* * * *", "needs 5 fields"},
+		{"* * * * * *", "needs 5 fields"},
+		{"60 * * * *", `invalid value "60" in the minute field`},
+		{"* 24 * * *", `invalid value "24" in the hour field`},
+		{"* * 0 * *", `invalid value "0" in the day of month field`},
+		{"* * * 13 *", `invalid value "13" in the month field`},
+		{"* * * * 8", `invalid value "8" in the day of week field`},
+		{"* * * * sunday", `invalid value "sunday" in the day of week field`},
+		{"*/0 * * * *", `invalid step "0" in the minute field`},
+		{"*/x * * * *", `invalid step "x" in the minute field`},
+		{"30-10 * * * *", `invalid range "30-10" in the minute field`},
+		{"* * * dec-jan *", `invalid range "dec-jan" in the month field`},
+		{"@yearly", "needs 5 fields"},
+		// Valid fields that never line up.
+		{"0 0 31 2 *", "never runs"},
+		{"0 0 30 feb *", "never runs"},
+		{"0 0 31 4,6,9,11 *", "never runs"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.expression)
+		if err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Fatalf("Parse(%q) error = %v, want one containing %q", test.expression, err, test.wantError)
+		}
+	}
+}
+
+func TestCronNextAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	local := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		expression string
+		from       time.Time
+		want       []time.Time
+	}{
+		// Clocks jump from 2:00 to 3:00 on March 9, 2:30 doesn't exist that day.
+		{"30 2 * * *", local(3, 8, 12, 0), []time.Time{local(3, 10, 2, 30), local(3, 11, 2, 30)}},
+		{"0 * * * *", local(3, 9, 0, 30), []time.Time{local(3, 9, 1, 0), local(3, 9, 3, 0), local(3, 9, 4, 0)}},
+		{"*/20 * * * *", local(3, 9, 1, 30), []time.Time{local(3, 9, 1, 40), local(3, 9, 3, 0), local(3, 9, 3, 20)}},
+		// Clocks go back from 2:00 to 1:00 on November 2, the repeated hour runs once.
+		{"30 1 * * *", local(11, 1, 12, 0), []time.Time{local(11, 2, 1, 30), local(11, 3, 1, 30)}},
+		{"0 * * * *", local(11, 2, 0, 30), []time.Time{local(11, 2, 1, 0), local(11, 2, 2, 0), local(11, 2, 3, 0)}},
+		// Daily runs keep their local time on both sides of the change, 23 or 25 hours apart.
+		{"0 9 * * *", local(3, 8, 12, 0), []time.Time{local(3, 9, 9, 0), local(3, 10, 9, 0)}},
+		{"0 9 * * *", local(11, 1, 12, 0), []time.Time{local(11, 2, 9, 0), local(11, 3, 9, 0)}},
+	}
+
+	for _, test := range tests {
+		cron, err := Parse(test.expression)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", test.expression, err)
+		}
+		next := test.from
+		for _, want := range test.want {
+			next = cron.Next(next)
+			if !next.Equal(want) || next.Location() != newYork {
+				t.Fatalf("%q: Next = %v, want %v", test.expression, next, want)
+			}
+		}
+	}
+}
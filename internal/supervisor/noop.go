@@ -0,0 +1,13 @@
+package supervisor
+
+/*
+Used when the game server is managed outside of the bot, every action reports ErrNotSupervised.
+*/
+type noopSupervisor struct{}
+
+func (noopSupervisor) Name() string           { return "none" }
+func (noopSupervisor) Start() error           { return ErrNotSupervised }
+func (noopSupervisor) Stop() error            { return ErrNotSupervised }
+func (noopSupervisor) Restart() error         { return ErrNotSupervised }
+func (noopSupervisor) Running() (bool, error) { return false, ErrNotSupervised }
+func (noopSupervisor) RecentOutput() string   { return "" }
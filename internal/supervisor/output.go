@@ -0,0 +1,50 @@
+package supervisor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// How much of the end of the server log is returned for alerts.
+const outputLimit = 16 * 1024
+
+/*
+Opens the server log, emptied on every start so it only holds the current run.
+The server writes to the file directly, so it keeps running when the bot stops.
+*/
+func openServerLog(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open server log: %w", err)
+	}
+	return file, nil
+}
+
+/*
+Returns the last outputLimit bytes of the server log, starting at a full line.
+*/
+func tailServerLog(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return ""
+	}
+	offset := max(info.Size()-outputLimit, 0)
+	content, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return ""
+	}
+	if offset > 0 {
+		if newline := bytes.IndexByte(content, '\n'); newline >= 0 {
+			content = content[newline+1:]
+		}
+	}
+	return string(content)
+}
@@ -0,0 +1,135 @@
+package supervisor
+
+import (
+	"time"
+)
+
+// Upper bound of the wait between two automatic restarts.
+const maxBackoff = 30 * time.Minute
+
+type Decision int
+
+const (
+	DecisionHealthy   Decision = iota
+	DecisionRecovered          // Healthy for FailuresBeforeRestart checks in a row after an automatic restart
+	DecisionFailing            // Unhealthy, but not restarting yet
+	DecisionRestart
+	DecisionThrottled // Unhealthy, but the hourly restart cap is reached
+)
+
+/*
+Decides when an unhealthy server gets restarted.
+A restart needs FailuresBeforeRestart failed checks in a row, waits for the backoff since the previous restart
+and is refused once MaxRestartsPerHour is reached. The backoff doubles after every restart up to maxBackoff
+and goes back to its base once the server passed every check for that long.
+*/
+type RestartPolicy struct {
+	FailuresBeforeRestart int
+	BaseBackoff           time.Duration
+	MaxRestartsPerHour    int
+
+	failures     int
+	healthy      int       // Healthy checks in a row
+	healthySince time.Time // First check of the healthy streak
+	backoff      time.Duration
+	nextRestart  time.Time
+	restarts     []time.Time // Automatic restarts of the last hour
+	recovering   bool
+}
+
+/*
+Snapshot of the restart policy state.
+*/
+type PolicyStatus struct {
+	Failures              int
+	FailuresBeforeRestart int
+	RestartsLastHour      int
+	MaxRestartsPerHour    int
+	LastRestart           time.Time // Zero when there was no automatic restart in the last hour
+	NextRestart           time.Time // Earliest time of the next automatic restart
+}
+
+/*
+Records the result of a health check and returns what to do about it.
+*/
+func (p *RestartPolicy) Observe(healthy bool, now time.Time) Decision {
+	p.pruneRestarts(now)
+	if p.backoff == 0 {
+		p.backoff = p.BaseBackoff
+	}
+
+	if healthy {
+		p.failures = 0
+		if p.healthy == 0 {
+			p.healthySince = now
+		}
+		p.healthy++
+		if now.Sub(p.healthySince) >= maxBackoff {
+			p.backoff = p.BaseBackoff
+		}
+		if p.recovering && p.healthy >= p.FailuresBeforeRestart {
+			p.recovering = false
+			return DecisionRecovered
+		}
+		return DecisionHealthy
+	}
+
+	p.healthy = 0
+	p.failures++
+	if p.failures < p.FailuresBeforeRestart || now.Before(p.nextRestart) {
+		return DecisionFailing
+	}
+	if len(p.restarts) >= p.MaxRestartsPerHour {
+		return DecisionThrottled
+	}
+	return DecisionRestart
+}
+
+/*
+Records an automatic restart, starting its backoff.
+*/
+func (p *RestartPolicy) RecordRestart(now time.Time) {
+	p.restarts = append(p.restarts, now)
+	p.failures = 0
+	p.healthy = 0
+	p.nextRestart = now.Add(p.backoff)
+	p.backoff = min(p.backoff*2, maxBackoff)
+	p.recovering = true
+}
+
+/*
+Forgets the failures, used after a manual restart or stop.
+*/
+func (p *RestartPolicy) Reset() {
+	p.failures = 0
+	p.recovering = false
+}
+
+func (p *RestartPolicy) Status(now time.Time) PolicyStatus {
+	p.pruneRestarts(now)
+	status := PolicyStatus{
+		Failures:              p.failures,
+		FailuresBeforeRestart: p.FailuresBeforeRestart,
+		RestartsLastHour:      len(p.restarts),
+		MaxRestartsPerHour:    p.MaxRestartsPerHour,
+		NextRestart:           p.nextRestart,
+	}
+	if len(p.restarts) > 0 {
+		status.LastRestart = p.restarts[len(p.restarts)-1]
+		// The cap frees up when the oldest restart leaves the hour window.
+		if len(p.restarts) >= p.MaxRestartsPerHour {
+			status.NextRestart = p.restarts[0].Add(time.Hour)
+		}
+	}
+	return status
+}
+
+func (p *RestartPolicy) pruneRestarts(now time.Time) {
+	kept := p.restarts[:0]
+	for _, restart := range p.restarts {
+		if now.Sub(restart) < time.Hour {
+			kept = append(kept, restart)
+		}
+	}
+	p.restarts = kept
+}
@@ -0,0 +1,95 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyBackoffAndHourlyCap(t *testing.T) {
+	policy := &RestartPolicy{FailuresBeforeRestart: 2, BaseBackoff: time.Minute, MaxRestartsPerHour: 2}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) time.Time { return start.Add(offset) }
+
+	steps := []struct {
+		offset  time.Duration
+		healthy bool
+		want    Decision
+		restart bool // Records the restart when the decision is DecisionRestart
+	}{
+		{0, true, DecisionHealthy, false},
+		{10 * time.Second, false, DecisionFailing, false},
+		{20 * time.Second, false, DecisionRestart, true},
+		// The first backoff is the base, 1 minute after the restart.
+		{30 * time.Second, false, DecisionFailing, false},
+		{40 * time.Second, false, DecisionFailing, false},
+		{80 * time.Second, false, DecisionRestart, true},
+		// The backoff doubled to 2 minutes.
+		{90 * time.Second, false, DecisionFailing, false},
+		{190 * time.Second, false, DecisionFailing, false},
+		{200 * time.Second, false, DecisionThrottled, false},
+		// Still capped until the first restart leaves the hour window.
+		{time.Hour + 10*time.Second, false, DecisionThrottled, false},
+		{time.Hour + 20*time.Second, false, DecisionRestart, true},
+	}
+
+	for _, step := range steps {
+		got := policy.Observe(step.healthy, at(step.offset))
+		if got != step.want {
+			t.Fatalf("Observe(%v) at +%v = %v, want %v", step.healthy, step.offset, got, step.want)
+		}
+		if step.restart {
+			policy.RecordRestart(at(step.offset))
+		}
+	}
+
+	status := policy.Status(at(time.Hour + 20*time.Second))
+	if status.RestartsLastHour != 2 {
+		t.Fatalf("RestartsLastHour = %d, want 2", status.RestartsLastHour)
+	}
+	// Capped again, freed when the restart at +80s leaves the window.
+	if want := at(time.Hour + 80*time.Second); !status.NextRestart.Equal(want) {
+		t.Fatalf("NextRestart = %v, want %v", status.NextRestart, want)
+	}
+}
+
+func TestRestartPolicyBackoffLimitAndReset(t *testing.T) {
+	policy := &RestartPolicy{FailuresBeforeRestart: 1, BaseBackoff: 10 * time.Minute, MaxRestartsPerHour: 100}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, want := range []time.Duration{10 * time.Minute, 20 * time.Minute, maxBackoff, maxBackoff} {
+		if got := policy.Observe(false, now); got != DecisionRestart {
+			t.Fatalf("Observe(false) = %v, want DecisionRestart", got)
+		}
+		policy.RecordRestart(now)
+		if got := policy.Status(now).NextRestart.Sub(now); got != want {
+			t.Fatalf("backoff = %v, want %v", got, want)
+		}
+		now = now.Add(want)
+	}
+
+	// A single healthy check long after the restart doesn't reset the backoff.
+	if got := policy.Observe(true, now); got != DecisionRecovered {
+		t.Fatalf("Observe(true) = %v, want DecisionRecovered", got)
+	}
+	policy.Observe(false, now.Add(time.Minute))
+	now = now.Add(2 * time.Minute)
+	policy.Observe(true, now)
+	policy.Observe(true, now.Add(maxBackoff-time.Second))
+	policy.Observe(false, now.Add(maxBackoff))
+	policy.RecordRestart(now.Add(maxBackoff))
+	if got := policy.Status(now).NextRestart.Sub(now.Add(maxBackoff)); got != maxBackoff {
+		t.Fatalf("backoff after a short healthy streak = %v, want %v", got, maxBackoff)
+	}
+
+	// Healthy for maxBackoff in a row, the backoff goes back to its base.
+	now = now.Add(maxBackoff)
+	for elapsed := time.Duration(0); elapsed <= maxBackoff; elapsed += time.Minute {
+		policy.Observe(true, now.Add(elapsed))
+	}
+	now = now.Add(maxBackoff + time.Minute)
+	policy.Observe(false, now)
+	policy.RecordRestart(now)
+	if got := policy.Status(now).NextRestart.Sub(now); got != policy.BaseBackoff {
+		t.Fatalf("backoff after staying healthy = %v, want %v", got, policy.BaseBackoff)
+	}
+}
@@ -0,0 +1,75 @@
+package supervisor
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+/*
+Checks the game server directly, without going through the public server list.
+*/
+type HealthProbe interface {
+	Name() string
+	// Returns why the server is unhealthy, nil when it is fine.
+	Check() error
+}
+
+/*
+Creates the probes enabled in the configuration, an empty slice when there are none.
+*/
+func NewProbes(cfg *config.Config) []HealthProbe {
+	var probes []HealthProbe
+	if cfg.ServerHeartbeatFile != "" {
+		probes = append(probes, &heartbeatProbe{path: cfg.ServerHeartbeatFile, maxAge: cfg.ServerHeartbeatMaxAge})
+	}
+	if cfg.ServerProbeAddress != "" {
+		probes = append(probes, &tcpProbe{address: cfg.ServerProbeAddress})
+	}
+	return probes
+}
+
+/*
+Expects the server script to touch a file regularly, a stale file means the server is stuck.
+*/
+type heartbeatProbe struct {
+	path   string
+	maxAge time.Duration
+}
+
+func (hp *heartbeatProbe) Name() string {
+	return "heartbeat"
+}
+
+func (hp *heartbeatProbe) Check() error {
+	info, err := os.Stat(hp.path)
+	if err != nil {
+		return fmt.Errorf("heartbeat file unreadable: %w", err)
+	}
+	if age := time.Since(info.ModTime()); age > hp.maxAge {
+		return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+	}
+	return nil
+}
+
+/*
+Expects the server to accept connections on a TCP port, such as its RCON port.
+*/
+type tcpProbe struct {
+	address string
+}
+
+func (tp *tcpProbe) Name() string {
+	return "port"
+}
+
+func (tp *tcpProbe) Check() error {
+	conn, err := net.DialTimeout("tcp", tp.address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("%s is not accepting connections: %w", tp.address, err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,190 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long the server gets to exit after being asked to before it is killed.
+const defaultStopTimeout = 15 * time.Second
+
+/*
+Runs the configured command line in its own process group.
+The PID is written to the PID file when one is configured, so a restarted bot still finds a server it started before.
+*/
+type processSupervisor struct {
+	command []string
+	dir     string
+	pidFile string
+	mu      sync.Mutex
+	logFile string
+	pid     int // Used when there is no PID file
+
+	stopTimeout time.Duration
+}
+
+func newProcessSupervisor(command []string, dir, pidFile, logFile string) (*processSupervisor, error) {
+	if !processGroupsSupported {
+		return nil, errors.New("the process supervisor is not supported on this OS, use the windows supervisor")
+	}
+	return &processSupervisor{
+		command: command,
+		dir:     dir,
+		pidFile: pidFile,
+		logFile: logFile,
+
+		stopTimeout: defaultStopTimeout,
+	}, nil
+}
+
+func (ps *processSupervisor) Name() string {
+	return "process"
+}
+
+func (ps *processSupervisor) Start() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.start()
+}
+
+func (ps *processSupervisor) Stop() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.stop()
+}
+
+func (ps *processSupervisor) Restart() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if err := ps.stop(); err != nil {
+		return err
+	}
+	return ps.start()
+}
+
+func (ps *processSupervisor) Running() (bool, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	pid, err := ps.readPID()
+	if err != nil || pid == 0 {
+		return false, err
+	}
+	return processAlive(pid), nil
+}
+
+func (ps *processSupervisor) RecentOutput() string {
+	if ps.logFile == "" {
+		return ""
+	}
+	return tailServerLog(ps.logFile)
+}
+
+func (ps *processSupervisor) start() error {
+	pid, err := ps.readPID()
+	if err != nil {
+		return err
+	}
+	if pid != 0 && processAlive(pid) {
+		return fmt.Errorf("game server is already running with PID %d", pid)
+	}
+
+	cmd := exec.Command(ps.command[0], ps.command[1:]...)
+	cmd.Dir = ps.dir
+	if ps.logFile != "" {
+		logFile, err := openServerLog(ps.logFile)
+		if err != nil {
+			return err
+		}
+		// The child keeps its own handle.
+		defer logFile.Close()
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", ps.command[0], err)
+	}
+	// Reaps the process once it exits.
+	go cmd.Wait()
+
+	return ps.writePID(cmd.Process.Pid)
+}
+
+/*
+Asks the whole process group to terminate, killing it when it is still alive after the stop timeout.
+*/
+func (ps *processSupervisor) stop() error {
+	pid, err := ps.readPID()
+	if err != nil {
+		return err
+	}
+	if pid == 0 || !processAlive(pid) {
+		return ps.writePID(0)
+	}
+
+	if err := terminateGroup(pid); err != nil {
+		return fmt.Errorf("failed to stop game server with PID %d: %w", pid, err)
+	}
+	deadline := time.Now().Add(ps.stopTimeout)
+	for processAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(250 * time.Millisecond)
+	}
+	if processAlive(pid) {
+		if err := killGroup(pid); err != nil {
+			return fmt.Errorf("failed to kill game server with PID %d: %w", pid, err)
+		}
+	}
+
+	return ps.writePID(0)
+}
+
+/*
+Returns 0 when no server was started.
+*/
+func (ps *processSupervisor) readPID() (int, error) {
+	if ps.pidFile == "" {
+		return ps.pid, nil
+	}
+
+	content, err := os.ReadFile(ps.pidFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read PID file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid PID file %s: %w", ps.pidFile, err)
+	}
+	return pid, nil
+}
+
+/*
+Stores the PID of the server, 0 clears it.
+*/
+func (ps *processSupervisor) writePID(pid int) error {
+	ps.pid = pid
+	if ps.pidFile == "" {
+		return nil
+	}
+
+	if pid == 0 {
+		if err := os.Remove(ps.pidFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove PID file: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(ps.pidFile, []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+/*
+Not a real test, it is the game server started by the supervisor in the other tests.
+The mode comes after "--": sleep waits to be stopped, ignore-term only dies to SIGKILL.
+*/
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "missing helper mode")
+		os.Exit(2)
+	}
+
+	switch args[1] {
+	case "sleep":
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown helper mode %q\n", args[1])
+		os.Exit(2)
+	}
+	fmt.Println("ready")
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func helperCommand(mode string) []string {
+	return []string{os.Args[0], "-test.run=^TestHelperProcess$", "--", mode}
+}
+
+/*
+Returns a supervisor running the helper process, with its PID file and log in a temporary directory.
+*/
+func newTestSupervisor(t *testing.T, mode string) *processSupervisor {
+	t.Helper()
+	t.Setenv("GO_WANT_HELPER_PROCESS", "1")
+
+	dir := t.TempDir()
+	ps, err := newProcessSupervisor(helperCommand(mode), dir, filepath.Join(dir, "server.pid"), filepath.Join(dir, "server.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if pid, _ := ps.readPID(); pid != 0 {
+			killGroup(pid)
+		}
+	})
+	return ps
+}
+
+/*
+Waits for the helper to print ready, so it has set up its signal handling before being stopped.
+*/
+func waitReady(t *testing.T, ps *processSupervisor) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if strings.Contains(ps.RecentOutput(), "ready") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("helper process never got ready, output: %q", ps.RecentOutput())
+}
+
+func readPIDFile(t *testing.T, ps *processSupervisor) int {
+	t.Helper()
+	pid, err := ps.readPID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pid
+}
+
+func TestProcessSupervisorStartStop(t *testing.T) {
+	ps := newTestSupervisor(t, "sleep")
+
+	if running, err := ps.Running(); err != nil || running {
+		t.Fatalf("Running() before Start = %v, %v, want false", running, err)
+	}
+	if err := ps.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, ps)
+
+	pid := readPIDFile(t, ps)
+	if pid == 0 {
+		t.Fatal("Start did not write the PID file")
+	}
+	if running, err := ps.Running(); err != nil || !running {
+		t.Fatalf("Running() after Start = %v, %v, want true", running, err)
+	}
+	if err := ps.Start(); err == nil {
+		t.Fatal("second Start succeeded while the server is running")
+	}
+
+	if err := ps.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if running, err := ps.Running(); err != nil || running {
+		t.Fatalf("Running() after Stop = %v, %v, want false", running, err)
+	}
+	if _, err := os.Stat(ps.pidFile); !os.IsNotExist(err) {
+		t.Fatalf("PID file still there after Stop: %v", err)
+	}
+	if processAlive(pid) {
+		t.Fatalf("PID %d still alive after Stop", pid)
+	}
+}
+
+func TestProcessSupervisorStopKillsAfterTimeout(t *testing.T) {
+	ps := newTestSupervisor(t, "ignore-term")
+	ps.stopTimeout = 500 * time.Millisecond
+
+	if err := ps.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, ps)
+	pid := readPIDFile(t, ps)
+
+	started := time.Now()
+	if err := ps.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed < ps.stopTimeout {
+		t.Fatalf("Stop returned after %v, before the %v grace period", elapsed, ps.stopTimeout)
+	}
+
+	// The kill is asynchronous, give the reaper a moment.
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if processAlive(pid) {
+		t.Fatalf("PID %d ignoring SIGTERM survived Stop", pid)
+	}
+}
+
+func TestProcessSupervisorStalePIDFile(t *testing.T) {
+	ps := newTestSupervisor(t, "sleep")
+
+	// Started without its own process group, like an unrelated process that reused the PID.
+	stranger := exec.Command(os.Args[0], helperCommand("sleep")[1:]...)
+	if err := stranger.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stranger.Process.Kill()
+		stranger.Wait()
+	})
+
+	// A process that exited, its PID is free.
+	dead := exec.Command("true")
+	if err := dead.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, pid := range map[string]int{"reused": stranger.Process.Pid, "dead": dead.Process.Pid} {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(ps.pidFile, []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if running, err := ps.Running(); err != nil || running {
+				t.Fatalf("Running() with a stale PID = %v, %v, want false", running, err)
+			}
+			if err := ps.Stop(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(ps.pidFile); !os.IsNotExist(err) {
+				t.Fatalf("stale PID file not cleared by Stop: %v", err)
+			}
+			if err := syscall.Kill(stranger.Process.Pid, 0); err != nil {
+				t.Fatalf("Stop signalled the process that reused the PID: %v", err)
+			}
+		})
+	}
+
+	if err := os.WriteFile(ps.pidFile, []byte(strconv.Itoa(stranger.Process.Pid)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.Start(); err != nil {
+		t.Fatalf("Start refused because of a stale PID: %v", err)
+	}
+	if pid := readPIDFile(t, ps); pid == stranger.Process.Pid {
+		t.Fatal("Start kept the stale PID")
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const processGroupsSupported = true
+
+/*
+Starts the command as the leader of a new process group, so stopping it also stops anything it spawned.
+*/
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+/*
+Returns whether the PID is still the server started by the bot: alive and leading its own process group.
+A PID reused by an unrelated process fails the check, so a stale PID file never gets another process group signalled.
+The server runs as the bot user, a process we may not signal (EPERM) is not ours.
+*/
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	pgid, err := syscall.Getpgid(pid)
+	return err == nil && pgid == pid
+}
+
+func terminateGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+func killGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package supervisor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Windows has no process groups to signal, the windows supervisor is used instead.
+const processGroupsSupported = false
+
+var errNoProcessGroups = errors.New("process groups are not supported on windows")
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func processAlive(pid int) bool {
+	return false
+}
+
+func terminateGroup(pid int) error {
+	return errNoProcessGroups
+}
+
+func killGroup(pid int) error {
+	return errNoProcessGroups
+}
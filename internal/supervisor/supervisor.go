@@ -0,0 +1,80 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/leonardomlouzas/GoldenSapling/internal/config"
+)
+
+// Returned by the no-op supervisor, the game server is managed outside of the bot.
+var ErrNotSupervised = errors.New("game server is not supervised by the bot")
+
+/*
+Starts, stops and checks the game server process.
+*/
+type ServerSupervisor interface {
+	Name() string
+	Start() error
+	Stop() error
+	Restart() error
+	// Reports whether the server process is alive, not whether the game is reachable.
+	Running() (bool, error)
+	// Returns the last lines printed by the server, empty when they are not available.
+	RecentOutput() string
+}
+
+/*
+Creates the supervisor selected by SERVER_SUPERVISOR.
+Without it, Windows hosts with a GAME_PATH keep the legacy taskkill behaviour and everything else is not supervised.
+*/
+func New(cfg *config.Config) (ServerSupervisor, error) {
+	kind := strings.ToLower(cfg.ServerSupervisor)
+	if kind == "" {
+		kind = "none"
+		if runtime.GOOS == "windows" && cfg.GamePath != "" {
+			kind = "windows"
+		}
+	}
+
+	switch kind {
+	case "windows":
+		if runtime.GOOS != "windows" {
+			return nil, fmt.Errorf("the windows supervisor can't run on %s", runtime.GOOS)
+		}
+		if cfg.GamePath == "" {
+			return nil, errors.New("the windows supervisor needs GAME_PATH")
+		}
+		return newWindowsSupervisor(cfg.GamePath, cfg.ServerLogFile), nil
+	case "process":
+		command := strings.Fields(cfg.ServerCommand)
+		if len(command) == 0 {
+			return nil, errors.New("the process supervisor needs SERVER_COMMAND")
+		}
+		return newProcessSupervisor(command, cfg.GamePath, cfg.ServerPIDFile, cfg.ServerLogFile)
+	case "systemd":
+		if cfg.ServerSystemdUnit == "" {
+			return nil, errors.New("the systemd supervisor needs SERVER_SYSTEMD_UNIT")
+		}
+		return newSystemdSupervisor(cfg.ServerSystemdUnit), nil
+	case "none":
+		return noopSupervisor{}, nil
+	default:
+		return nil, fmt.Errorf("unknown SERVER_SUPERVISOR %q, expected windows, process, systemd or none", cfg.ServerSupervisor)
+	}
+}
+
+/*
+Snapshot of the supervised server shown to admins.
+*/
+type Status struct {
+	Supervisor string
+	Running    bool
+	RunningErr error            // Set when the process state could not be checked
+	Listed     *bool            // Whether the server is in the server list, nil when unknown
+	Probes     map[string]error // Result of each health probe, nil errors passed
+	Paused     bool             // Stopped by an admin, automatic restarts are off
+	Policy     PolicyStatus
+}
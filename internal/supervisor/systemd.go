@@ -0,0 +1,64 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/*
+Delegates to a systemd unit, the bot user needs to be allowed to manage it.
+*/
+type systemdSupervisor struct {
+	unit string
+}
+
+func newSystemdSupervisor(unit string) *systemdSupervisor {
+	return &systemdSupervisor{unit: unit}
+}
+
+func (ss *systemdSupervisor) Name() string {
+	return "systemd"
+}
+
+func (ss *systemdSupervisor) Start() error {
+	return ss.systemctl("start")
+}
+
+func (ss *systemdSupervisor) Stop() error {
+	return ss.systemctl("stop")
+}
+
+func (ss *systemdSupervisor) Restart() error {
+	return ss.systemctl("restart")
+}
+
+func (ss *systemdSupervisor) Running() (bool, error) {
+	err := exec.Command("systemctl", "is-active", "--quiet", ss.unit).Run()
+	if err == nil {
+		return true, nil
+	}
+	// is-active exits with a non-zero code when the unit is not active.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check %s: %w", ss.unit, err)
+}
+
+func (ss *systemdSupervisor) RecentOutput() string {
+	output, err := exec.Command("journalctl", "--unit", ss.unit, "--lines", "40", "--no-pager", "--output", "cat").Output()
+	if err != nil {
+		return fmt.Sprintf("failed to read the %s journal: %v", ss.unit, err)
+	}
+	return string(output)
+}
+
+func (ss *systemdSupervisor) systemctl(action string) error {
+	output, err := exec.Command("systemctl", action, ss.unit).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s %s failed: %w: %s", action, ss.unit, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package supervisor
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const windowsServerExe = "r5apex_ds.exe"
+
+/*
+Runs the dedicated server executable from the game folder and finds it again by image name.
+*/
+type windowsSupervisor struct {
+	gamePath string
+	logFile  string
+}
+
+func newWindowsSupervisor(gamePath, logFile string) *windowsSupervisor {
+	return &windowsSupervisor{gamePath: gamePath, logFile: logFile}
+}
+
+func (ws *windowsSupervisor) Name() string {
+	return "windows"
+}
+
+func (ws *windowsSupervisor) Start() error {
+	cmd := exec.Command(filepath.Join(ws.gamePath, windowsServerExe))
+	cmd.Dir = ws.gamePath
+	if ws.logFile != "" {
+		logFile, err := openServerLog(ws.logFile)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", windowsServerExe, err)
+	}
+	// Reaps the process once it exits.
+	go cmd.Wait()
+	return nil
+}
+
+func (ws *windowsSupervisor) Stop() error {
+	running, err := ws.Running()
+	if err != nil {
+		return err
+	}
+	if !running {
+		return nil
+	}
+
+	output, err := exec.Command("taskkill", "/f", "/t", "/im", windowsServerExe).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to stop %s: %w: %s", windowsServerExe, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (ws *windowsSupervisor) Restart() error {
+	if err := ws.Stop(); err != nil {
+		return err
+	}
+	return ws.Start()
+}
+
+func (ws *windowsSupervisor) Running() (bool, error) {
+	output, err := exec.Command("tasklist", "/fi", "IMAGENAME eq "+windowsServerExe, "/nh").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list processes: %w", err)
+	}
+	return strings.Contains(strings.ToLower(string(output)), windowsServerExe), nil
+}
+
+func (ws *windowsSupervisor) RecentOutput() string {
+	if ws.logFile == "" {
+		return ""
+	}
+	return tailServerLog(ws.logFile)
+}